@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	graphformatter "github.com/HappyR0b0t/graph-formatting/pkg"
+)
+
+// renderFunc writes the output of structs, grouped into series by the
+// groupBy labels.
+type renderFunc func(structs []graphformatter.Transaction, groupBy []string, w io.Writer) error
+
+// expandInputs resolves glob patterns into paths, in order and without
+// duplicates. Patterns without matches are kept, so that reading them
+// reports the missing file.
+func expandInputs(patterns []string) []string {
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+func readInput(path string) ([]graphformatter.Transaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return graphformatter.ReadTransactions(file)
+}
+
+// forEach calls f for every path with at most jobs calls running at a
+// time. Errors are logged per path and counted.
+func forEach(paths []string, jobs int, f func(i int, path string) error) int {
+	jobs = max(1, min(jobs, len(paths)))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	next := make(chan int)
+	for n := 0; n < jobs; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := f(i, paths[i]); err != nil {
+					log.Printf("%s: %v", paths[i], err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return failed
+}
+
+func batchError(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d inputs failed", failed, total)
+}
+
+// writeCombined reads all inputs concurrently and writes one output to
+// stdout. Unless merged, the transactions of every file are labeled with
+// the file and become a series of their own. Inputs that fail are left
+// out.
+func writeCombined(paths []string, jobs int, merge bool, render renderFunc) error {
+	inputs := make([][]graphformatter.Transaction, len(paths))
+	failed := forEach(paths, jobs, func(i int, path string) error {
+		structs, err := readInput(path)
+		inputs[i] = structs
+		return err
+	})
+	if failed == len(paths) {
+		return batchError(failed, len(paths))
+	}
+
+	var groupBy []string
+	if !merge && len(paths) > 1 {
+		groupBy = []string{"file"}
+	}
+	structs := []graphformatter.Transaction{}
+	for i, input := range inputs {
+		for _, tx := range input {
+			if groupBy != nil {
+				labels := graphformatter.Labels{"file": paths[i]}
+				for name, value := range tx.Labels.Labels() {
+					if name != "file" {
+						labels[name] = value
+					}
+				}
+				tx.Labels = labels.Key()
+			}
+			structs = append(structs, tx)
+		}
+	}
+	if err := render(structs, groupBy, os.Stdout); err != nil {
+		return err
+	}
+	return batchError(failed, len(paths))
+}
+
+// writeEach processes every input concurrently and writes its output to
+// a file of the same name in dir, with the extension of the output format.
+func writeEach(paths []string, jobs int, dir, output string, render renderFunc) error {
+	extension := "." + output
+	if output == "terminal" {
+		extension = ".txt"
+	}
+	targets := make([]string, len(paths))
+	owners := map[string]string{}
+	for i, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		targets[i] = filepath.Join(dir, name+extension)
+		if owner, ok := owners[targets[i]]; ok {
+			return fmt.Errorf("inputs %s and %s would both be written to %s", owner, path, targets[i])
+		}
+		owners[targets[i]] = path
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	failed := forEach(paths, jobs, func(i int, path string) error {
+		structs, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		var b bytes.Buffer
+		if err := render(structs, nil, &b); err != nil {
+			return err
+		}
+		return os.WriteFile(targets[i], b.Bytes(), 0o644)
+	})
+	return batchError(failed, len(paths))
+}
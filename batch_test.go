@@ -0,0 +1,168 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	graphformatter "github.com/HappyR0b0t/graph-formatting/pkg"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json", "c.jsonl"} {
+		writeFile(t, dir, name, "[]")
+	}
+	in := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{name: "Glob in order", patterns: []string{in("*.json"), in("c.jsonl")}, expected: []string{in("a.json"), in("b.json"), in("c.jsonl")}},
+		{name: "Without duplicates", patterns: []string{in("b.json"), in("*.json")}, expected: []string{in("b.json"), in("a.json")}},
+		{name: "Missing kept", patterns: []string{in("missing.json"), in("none-*.json")}, expected: []string{in("missing.json"), in("none-*.json")}},
+		{name: "Malformed pattern kept", patterns: []string{in("[.json")}, expected: []string{in("[.json")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := expandInputs(tt.patterns); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expandInputs() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+// countRender writes the number of transactions it is given.
+func countRender(structs []graphformatter.Transaction, groupBy []string, w io.Writer) error {
+	_, err := io.WriteString(w, strconv.Itoa(len(structs)))
+	return err
+}
+
+func TestWriteEach(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.json", `[{"value":1,"timestamp":1},{"value":2,"timestamp":2}]`)
+	b := writeFile(t, dir, "b.jsonl", "{\"value\":1,\"timestamp\":1}\n")
+	missing := filepath.Join(dir, "missing.json")
+	other := filepath.Join(t.TempDir(), "a.jsonl")
+
+	t.Run("One output per input", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "reports")
+		if err := writeEach([]string{a, b}, 2, out, "json", countRender); err != nil {
+			t.Fatal(err)
+		}
+		for name, expected := range map[string]string{"a.json": "2", "b.json": "1"} {
+			data, err := os.ReadFile(filepath.Join(out, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != expected {
+				t.Errorf("writeEach() wrote %q to %s, want %q", data, name, expected)
+			}
+		}
+	})
+
+	t.Run("Terminal output", func(t *testing.T) {
+		out := t.TempDir()
+		if err := writeEach([]string{b}, 1, out, "terminal", countRender); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "b.txt")); err != nil {
+			t.Errorf("writeEach() didn't write b.txt: %v", err)
+		}
+	})
+
+	t.Run("Failures counted", func(t *testing.T) {
+		out := t.TempDir()
+		err := writeEach([]string{a, missing, b}, 2, out, "json", countRender)
+		if err == nil || err.Error() != "1 of 3 inputs failed" {
+			t.Errorf("writeEach() error = %v, want 1 of 3 inputs failed", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "b.json")); err != nil {
+			t.Errorf("writeEach() didn't write the other inputs: %v", err)
+		}
+	})
+
+	t.Run("Colliding names", func(t *testing.T) {
+		out := t.TempDir()
+		if err := writeEach([]string{a, other}, 2, out, "json", countRender); err == nil {
+			t.Errorf("writeEach() error = nil, want error for two inputs named a")
+		}
+		if entries, _ := os.ReadDir(out); len(entries) != 0 {
+			t.Errorf("writeEach() wrote %d files before failing", len(entries))
+		}
+	})
+}
+
+func TestWriteCombined(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.json", `[{"value":1,"timestamp":1,"labels":{"file":"x","merchant":"m"}}]`)
+	b := writeFile(t, dir, "b.json", `[{"value":2,"timestamp":2}]`)
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name    string
+		paths   []string
+		merge   bool
+		labels  []graphformatter.Labels
+		groupBy []string
+		err     string
+	}{
+		{
+			name:    "Labeled by file",
+			paths:   []string{a, b},
+			labels:  []graphformatter.Labels{{"file": a, "merchant": "m"}, {"file": b}},
+			groupBy: []string{"file"},
+		},
+		{
+			name:   "Merged",
+			paths:  []string{a, b},
+			merge:  true,
+			labels: []graphformatter.Labels{{"file": "x", "merchant": "m"}, nil},
+		},
+		{
+			name:   "Single input",
+			paths:  []string{b},
+			labels: []graphformatter.Labels{nil},
+		},
+		{
+			name:    "Failed input left out",
+			paths:   []string{a, missing, b},
+			labels:  []graphformatter.Labels{{"file": a, "merchant": "m"}, {"file": b}},
+			groupBy: []string{"file"},
+			err:     "1 of 3 inputs failed",
+		},
+		{
+			name:  "All failed",
+			paths: []string{missing},
+			err:   "1 of 1 inputs failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var labels []graphformatter.Labels
+			var groupBy []string
+			render := func(structs []graphformatter.Transaction, g []string, w io.Writer) error {
+				for _, tx := range structs {
+					labels = append(labels, tx.Labels.Labels())
+				}
+				groupBy = g
+				return nil
+			}
+			err := writeCombined(tt.paths, 2, tt.merge, render)
+			if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+				t.Errorf("writeCombined() error = %v, want %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(labels, tt.labels) || !reflect.DeepEqual(groupBy, tt.groupBy) {
+				t.Errorf("writeCombined() rendered labels %v by %v, want %v by %v", labels, groupBy, tt.labels, tt.groupBy)
+			}
+		})
+	}
+}
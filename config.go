@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFlags are the flags that select a config file and can't be set
+// by one.
+var configFlags = map[string]bool{"config": true, "config-profile": true, "print-config": true}
+
+// config holds settings by flag name, and named profiles of settings that
+// take precedence over the top-level ones.
+type config struct {
+	settings map[string]interface{}
+	profiles map[string]map[string]interface{}
+}
+
+// readConfig reads a JSON, YAML or TOML config file, by its extension.
+func readConfig(path string) (config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config{}, err
+	}
+	settings := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&settings)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &settings)
+	case ".toml":
+		err = toml.Unmarshal(data, &settings)
+	default:
+		return config{}, fmt.Errorf("unknown config format %q, use .json, .yaml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c := config{settings: settings, profiles: map[string]map[string]interface{}{}}
+	if profiles, ok := settings["profiles"]; ok {
+		delete(settings, "profiles")
+		table, ok := profiles.(map[string]interface{})
+		if !ok {
+			return config{}, fmt.Errorf("%s: profiles must be a table of profiles", path)
+		}
+		for name, profile := range table {
+			if c.profiles[name], ok = profile.(map[string]interface{}); !ok {
+				return config{}, fmt.Errorf("%s: profile %q must be a table of settings", path, name)
+			}
+		}
+	}
+	return c, nil
+}
+
+// applyConfig sets the flags that weren't given on the command line from
+// the config file at path, first from its top-level settings and then
+// from profile, if any.
+func applyConfig(flags *flag.FlagSet, path, profile string) error {
+	if path == "" {
+		if profile != "" {
+			return fmt.Errorf("config profile %q needs a config file", profile)
+		}
+		return nil
+	}
+	c, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	layers := []map[string]interface{}{c.settings}
+	if profile != "" {
+		settings, ok := c.profiles[profile]
+		if !ok {
+			return fmt.Errorf("unknown config profile %q in %s", profile, path)
+		}
+		layers = append(layers, settings)
+	}
+
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, settings := range layers {
+		for name, value := range settings {
+			if flags.Lookup(name) == nil || configFlags[name] {
+				return fmt.Errorf("unknown setting %q in %s", name, path)
+			}
+			if given[name] {
+				continue
+			}
+			values, ok := value.([]interface{})
+			if !ok {
+				values = []interface{}{value}
+			}
+			for _, v := range values {
+				value, err := configValue(v)
+				if err == nil {
+					err = flags.Set(name, value)
+				}
+				if err != nil {
+					return fmt.Errorf("setting %q in %s: %w", name, path, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// configValue turns a decoded setting into its flag value. YAML and TOML
+// decode unquoted dates and times into time.Time, which is written the way
+// parseTime reads it: TOML local dates as dates, other times as RFC 3339.
+// TOML local date-times and times have no offset and are rejected.
+func configValue(v interface{}) (string, error) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return fmt.Sprint(v), nil
+	}
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02"), nil
+	case "datetime-local", "time-local":
+		return "", fmt.Errorf("time %s has no offset, add one like Z or +01:00", t.Format("2006-01-02T15:04:05"))
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// printConfig writes the effective settings as a JSON config file that
+// applyConfig reads back. Values are written as given on the command line,
+// so durations stay like "1s"; repeated flags are lists.
+func printConfig(w io.Writer, flags *flag.FlagSet) error {
+	settings := map[string]interface{}{}
+	flags.VisitAll(func(f *flag.Flag) {
+		if configFlags[f.Name] {
+			return
+		}
+		if layouts, ok := f.Value.(layoutFlag); ok {
+			settings[f.Name] = layouts.Get()
+		} else {
+			settings[f.Name] = f.Value.String()
+		}
+	})
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(settings)
+}
+
+// layoutFlag collects repeated INTERVAL=layout flags.
+type layoutFlag map[string]string
+
+func (l layoutFlag) String() string {
+	return strings.Join(l.Get().([]string), ",")
+}
+
+func (l layoutFlag) Set(s string) error {
+	interval, layout, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("label layout %q is not of the form INTERVAL=layout", s)
+	}
+	l[interval] = layout
+	return nil
+}
+
+func (l layoutFlag) Get() interface{} {
+	layouts := []string{}
+	for interval, layout := range l {
+		layouts = append(layouts, interval+"="+layout)
+	}
+	sort.Strings(layouts)
+	return layouts
+}
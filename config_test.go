@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testFlags returns a flag set with a setting of every kind of flag the
+// command line has.
+func testFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("interval", "HOUR", "")
+	flags.Int("max-points", 500, "")
+	flags.Duration("poll", time.Second, "")
+	flags.Bool("cumulative", false, "")
+	flags.String("from", "", "")
+	flags.String("to", "", "")
+	flags.Var(layoutFlag{}, "label-layout", "")
+	flags.String("config", "", "")
+	return flags
+}
+
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// values returns the values of all flags by name.
+func values(flags *flag.FlagSet) map[string]string {
+	result := map[string]string{}
+	flags.VisitAll(func(f *flag.Flag) {
+		result[f.Name] = f.Value.String()
+	})
+	return result
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		settings map[string]string
+		profiles []string
+		wantErr  bool
+	}{
+		{
+			name:     "JSON",
+			file:     "graph.json",
+			data:     `{"interval": "DAY", "max-points": 48, "profiles": {"daily": {"interval": "DAY"}}}`,
+			settings: map[string]string{"interval": "DAY", "max-points": "48"},
+			profiles: []string{"daily"},
+		},
+		{
+			name:     "YAML",
+			file:     "graph.yml",
+			data:     "interval: DAY\nmax-points: 48\nprofiles:\n  daily:\n    interval: DAY\n",
+			settings: map[string]string{"interval": "DAY", "max-points": "48"},
+			profiles: []string{"daily"},
+		},
+		{
+			name:     "TOML",
+			file:     "graph.toml",
+			data:     "interval = \"DAY\"\nmax-points = 48\n[profiles.daily]\ninterval = \"DAY\"\n",
+			settings: map[string]string{"interval": "DAY", "max-points": "48"},
+			profiles: []string{"daily"},
+		},
+		{name: "Unknown format", file: "graph.ini", data: "interval=DAY", wantErr: true},
+		{name: "Malformed", file: "graph.json", data: `{"interval":`, wantErr: true},
+		{name: "Profiles not a table", file: "graph.json", data: `{"profiles": ["daily"]}`, wantErr: true},
+		{name: "Profile not a table", file: "graph.json", data: `{"profiles": {"daily": "DAY"}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := readConfig(writeFile(t, t.TempDir(), tt.file, tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			settings := map[string]string{}
+			for name, value := range c.settings {
+				settings[name], _ = configValue(value)
+			}
+			if !reflect.DeepEqual(settings, tt.settings) {
+				t.Errorf("readConfig() settings = %v, want %v", settings, tt.settings)
+			}
+			profiles := []string{}
+			for name := range c.profiles {
+				profiles = append(profiles, name)
+			}
+			if !reflect.DeepEqual(profiles, tt.profiles) {
+				t.Errorf("readConfig() profiles = %v, want %v", profiles, tt.profiles)
+			}
+		})
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	yaml := "interval: DAY\nmax-points: 48\nlabel-layout: [DAY=02.01., HOUR=15h]\n" +
+		"profiles:\n  dash:\n    interval: HOUR\n    poll: 5s\n"
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		args     []string
+		profile  string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "Top-level settings",
+			file:     "graph.yaml",
+			data:     yaml,
+			expected: map[string]string{"interval": "DAY", "max-points": "48", "label-layout": "DAY=02.01.,HOUR=15h"},
+		},
+		{
+			name:     "Profile over top-level",
+			file:     "graph.yaml",
+			data:     yaml,
+			profile:  "dash",
+			expected: map[string]string{"interval": "HOUR", "max-points": "48", "poll": "5s"},
+		},
+		{
+			name:     "Flags over the file",
+			file:     "graph.yaml",
+			data:     yaml,
+			args:     []string{"-interval", "WEEK", "-poll", "2s"},
+			profile:  "dash",
+			expected: map[string]string{"interval": "WEEK", "max-points": "48", "poll": "2s"},
+		},
+		{
+			name:     "YAML dates",
+			file:     "graph.yaml",
+			data:     "from: 2021-03-01\nto: 2021-03-20T12:00:00+01:00\n",
+			expected: map[string]string{"from": "2021-03-01T00:00:00Z", "to": "2021-03-20T12:00:00+01:00"},
+		},
+		{
+			name:     "TOML dates",
+			file:     "graph.toml",
+			data:     "from = 2021-03-01\nto = 2021-03-20T00:00:00Z\n",
+			expected: map[string]string{"from": "2021-03-01", "to": "2021-03-20T00:00:00Z"},
+		},
+		{name: "TOML local date-time", file: "graph.toml", data: "to = 2021-03-20T00:00:00\n", wantErr: true},
+		{name: "Unknown setting", file: "graph.json", data: `{"intervall": "DAY"}`, wantErr: true},
+		{name: "Config flag", file: "graph.json", data: `{"config": "other.json"}`, wantErr: true},
+		{name: "Invalid value", file: "graph.json", data: `{"poll": "often"}`, wantErr: true},
+		{name: "Unknown profile", file: "graph.yaml", data: yaml, profile: "weekly", wantErr: true},
+		{name: "Profile without file", profile: "dash", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := testFlags()
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, t.TempDir(), tt.file, tt.data)
+			}
+			err := applyConfig(flags, path, tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result := values(flags)
+			for name, value := range tt.expected {
+				if result[name] != value {
+					t.Errorf("applyConfig() %s = %q, want %q", name, result[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	flags := testFlags()
+	args := []string{"-interval", "DAY", "-poll", "1m30s", "-cumulative", "-from", "2021-03-01", "-label-layout", "DAY=02.01.", "-label-layout", "HOUR=15h"}
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	var printed bytes.Buffer
+	if err := printConfig(&printed, flags); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "cumulative": "true",
+  "from": "2021-03-01",
+  "interval": "DAY",
+  "label-layout": [
+    "DAY=02.01.",
+    "HOUR=15h"
+  ],
+  "max-points": "500",
+  "poll": "1m30s",
+  "to": ""
+}
+`
+	if printed.String() != expected {
+		t.Errorf("printConfig() = %s, want %s", printed.String(), expected)
+	}
+
+	// The printed settings read back into the same values.
+	read := testFlags()
+	if err := applyConfig(read, writeFile(t, t.TempDir(), "printed.json", printed.String()), ""); err != nil {
+		t.Fatal(err)
+	}
+	if result, want := values(read), values(flags); !reflect.DeepEqual(result, want) {
+		t.Errorf("applyConfig() of the printed config = %v, want %v", result, want)
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/HappyR0b0t/graph-formatting
 
 go 1.22.1
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
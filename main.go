@@ -1,48 +1,450 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
 	graphformatter "github.com/HappyR0b0t/graph-formatting/pkg"
 )
 
+var graph = map[int]int64{
+	1:  1616026248,
+	2:  1616019048,
+	3:  1616022648,
+	4:  1615889448,
+	5:  1615871448,
+	6:  1234545757,
+	7:  1613672577,
+	8:  1615493354,
+	9:  1614849048,
+	10: 1613639545,
+	11: 1610961145,
+	12: 1615453945,
+	13: 1615972345,
+	14: 1615885945,
+	15: 1615799545,
+	16: 1615626745,
+	17: 1616015448,
+}
+
 func main() {
-	interval := "HOUR"
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serve(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) == 1 {
+		formatSample()
+		return
+	}
+	if err := format(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// formatSample formats the sample graph by HOUR with the original
+// functions, as the program always did when run without arguments.
+func formatSample() {
 	structs := []graphformatter.Transaction{}
+	structs = graphformatter.SliceSorter(graphformatter.SliceFiller(structs, graph))
+	graphformatter.TimestampToUnixTime(graphformatter.TimeDifferenceHour(structs))
+}
 
-	graph := map[int]int64{ 
-		1: 1616026248,
-		2: 1616019048,
-		3: 1616022648,
-		4: 1615889448,
-		5: 1615871448,
-		6: 1234545757, 
-		7: 1613672577,
-		8: 1615493354,
-		9: 1614849048,
-		10: 1613639545,
-		11: 1610961145,
-		12: 1615453945,
-		13: 1615972345,
-		14: 1615885945,
-		15: 1615799545,
-		16: 1615626745,
-		17: 1616015448,
+func format(args []string) error {
+	flags := flag.NewFlagSet("graph-formatting", flag.ExitOnError)
+	interval := flags.String("interval", graphformatter.IntervalHour, "bucket interval: MONTH, WEEK, DAY, HOUR, steps like 5m or 6h, or AUTO")
+	maxPoints := flags.Int("max-points", graphformatter.DefaultMaxPoints, "maximum number of buckets picked by the AUTO interval")
+	timezone := flags.String("timezone", "UTC", "timezone the buckets are computed in")
+	aggregation := flags.String("aggregation", graphformatter.AggregationSum, "SUM, COUNT, AVG, MIN, MAX or P<percentile>")
+	input := flags.String("input", "", "JSON or JSON Lines file or glob pattern with transactions; more can follow the flags, the sample graph if none")
+	merge := flags.Bool("merge", false, "merge the transactions of several inputs into one series instead of one series per file")
+	outputDir := flags.String("output-dir", "", "write one output per input into this directory instead of a combined output")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "number of inputs processed concurrently")
+	filter := flags.String("filter", "", `keep only matching transactions, e.g. 'value > 0 && label merchant == "x"'`)
+	clamp := flags.String("clamp", "", "clip values to a range min:max instead of dropping outliers")
+	validate := flags.String("validate", "", "check data quality with a policy REJECT, DROP or WARN, per issue kind like DROP,NEGATIVE=WARN; WARN if only checks are given")
+	from := flags.String("from", "", "earliest valid timestamp, RFC 3339 or a date")
+	to := flags.String("to", "", "latest valid timestamp, RFC 3339 or a date")
+	maxDistance := flags.Duration("max-distance", 0, "largest valid distance of a timestamp from the median timestamp")
+	futureTolerance := flags.Duration("future-tolerance", graphformatter.DefaultFutureTolerance, "how far timestamps may lie in the future")
+	noNegative := flags.Bool("no-negative", false, "report negative values")
+	dedup := flags.String("dedup", "", "drop repeated transactions with the same VALUE and labels or the same ID")
+	dedupTolerance := flags.Duration("dedup-tolerance", 0, "how far apart transactions with the same VALUE still count as repeated")
+	follow := flags.Bool("watch", false, "follow a JSON Lines input and redraw the output whenever transactions are appended")
+	poll := flags.Duration("poll", time.Second, "how often the watched input is checked for new transactions")
+	lateness := flags.Duration("lateness", 0, "how long past the end of a bucket late transactions are still counted when watching")
+	smooth := flags.String("smooth", "", "smoothing as method:window, e.g. sma:3, ema:6h or centered:24h")
+	counter := flags.String("counter", "", "treat values as counters: INCREASE or RATE per bucket, or DELTA for gauges")
+	cumulative := flags.Bool("cumulative", false, "output the running total of the series")
+	reset := flags.String("reset", "", "restart the running total every DAY, WEEK, MONTH or YEAR")
+	compare := flags.String("compare", "", "compare with the previous DAY, WEEK, MONTH, YEAR or a duration like 36h")
+	anomalies := flags.String("anomalies", "", "flag anomalies with ZSCORE, MAD or SEASONAL")
+	anomalyWindow := flags.String("anomaly-window", "24", "baseline of the anomaly detection as buckets or a duration")
+	anomalyThreshold := flags.Float64("anomaly-threshold", graphformatter.DefaultAnomalyThreshold, "score from which a bucket is flagged")
+	season := flags.String("season", "WEEK", "season of the SEASONAL anomaly detection: DAY, WEEK, MONTH, YEAR or a duration like 36h")
+	forecast := flags.String("forecast", "", "extend the series with LINEAR, NAIVE or HOLTWINTERS")
+	horizon := flags.Int("horizon", 24, "number of buckets to forecast")
+	forecastSeason := flags.String("forecast-season", "DAY", "seasonality of NAIVE and HOLTWINTERS, DAY or WEEK")
+	level := flags.Float64("level", 0.95, "coverage of the prediction intervals")
+	histogram := flags.String("histogram", "", "output a histogram of values per bucket with bins linear:N, log:N or edges:e0,e1,...")
+	profile := flags.Bool("profile", false, "output a heatmap of hour of day by weekday instead of buckets")
+	normalize := flags.Bool("normalize", false, "weigh every week equally in the profile")
+	calendar := flags.Bool("calendar", false, "output a calendar heatmap of DAY buckets per year")
+	weekStart := flags.String("week-start", "ISO", "first day of the calendar weeks: ISO or a weekday like SUNDAY")
+	labelStyle := flags.String("label-style", "", "label every bucket in SHORT or LONG style")
+	locale := flags.String("locale", "en", "language of the labels: en, de, fr or es")
+	layouts := layoutFlag{}
+	flags.Var(layouts, "label-layout", "Go time layout of the labels of an interval as INTERVAL=layout, e.g. DAY=02.01.2006; repeatable")
+	output := flags.String("output", "json", "output format, json, csv for histograms or terminal and svg for profiles and calendars")
+	configPath := flags.String("config", "", "JSON, YAML or TOML file with default settings by flag name")
+	configProfile := flags.String("config-profile", "", "named profile of the config file to apply on top of its top-level settings")
+	printCfg := flags.Bool("print-config", false, "print the effective settings as JSON and exit")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), "Usage: graph-formatting [flags] [inputs]\n       graph-formatting serve [flags]\n\n"+
+			"Run without any arguments, it only formats the sample graph by HOUR and prints nothing, as it always did.\n"+
+			"Given any flag, e.g. -interval HOUR, it prints the buckets in the -output format.\n\n")
+		flags.PrintDefaults()
 	}
+	flags.Parse(args)
 
-	structs = graphformatter.SliceSorter(graphformatter.SliceFiller(structs, graph))
+	if err := applyConfig(flags, *configPath, *configProfile); err != nil {
+		return err
+	}
+	if *printCfg {
+		return printConfig(os.Stdout, flags)
+	}
+
+	loc, err := graphformatter.LoadLocation(*timezone)
+	if err != nil {
+		return err
+	}
+	opts := graphformatter.Options{Interval: *interval, Location: loc, Aggregation: *aggregation, MaxPoints: *maxPoints}
+	counterMode, err := graphformatter.ParseCounter(*counter, *aggregation)
+	if err != nil {
+		return err
+	}
+
+	var predicate graphformatter.Predicate
+	if *filter != "" {
+		if predicate, err = graphformatter.ParseFilter(*filter, loc); err != nil {
+			return err
+		}
+	}
+
+	var clampMin, clampMax int
+	if *clamp != "" {
+		if clampMin, clampMax, err = graphformatter.ParseClamp(*clamp); err != nil {
+			return err
+		}
+	}
+
+	// The checks validate with WARN unless a policy is given. Settings
+	// equal to the default, as in printed configs, don't count.
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "from", "to", "max-distance", "future-tolerance", "no-negative":
+			if *validate == "" && f.Value.String() != f.DefValue {
+				*validate = graphformatter.PolicyWarn
+			}
+		}
+	})
+	var validation *graphformatter.ValidationOptions
+	if *validate != "" {
+		policy, policies, err := graphformatter.ParsePolicies(*validate)
+		if err != nil {
+			return err
+		}
+		validation = &graphformatter.ValidationOptions{
+			MaxDistance:      *maxDistance,
+			FutureTolerance:  *futureTolerance,
+			DisallowNegative: *noNegative,
+			Counter:          counterMode == graphformatter.CounterIncrease || counterMode == graphformatter.CounterRate,
+			Policy:           policy,
+			Policies:         policies,
+		}
+		if validation.From, err = parseTime(*from, loc); err != nil {
+			return err
+		}
+		if validation.To, err = parseTime(*to, loc); err != nil {
+			return err
+		}
+	}
+
+	// finish applies the transforms to the buckets of response and writes
+	// it.
+	finish := func(response graphformatter.FormatResponse, w io.Writer) error {
+		var err error
+		if counterMode == graphformatter.CounterRate {
+			err = response.Transform(func(points []graphformatter.Point) ([]graphformatter.Point, error) {
+				return graphformatter.PerSecond(points, response.Interval, loc)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *smooth != "" {
+			method, window, err := graphformatter.ParseSmoothing(*smooth)
+			if err != nil {
+				return err
+			}
+			err = response.Transform(func(points []graphformatter.Point) ([]graphformatter.Point, error) {
+				return graphformatter.Smooth(points, method, window)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *cumulative {
+			err = response.Transform(func(points []graphformatter.Point) ([]graphformatter.Point, error) {
+				return graphformatter.Cumulative(points, *reset, loc)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *compare != "" {
+			offset, err := graphformatter.ParseOffset(*compare)
+			if err != nil {
+				return err
+			}
+			if err := response.Compare(offset); err != nil {
+				return err
+			}
+		}
+
+		if *anomalies != "" {
+			window, err := graphformatter.ParseWindow(*anomalyWindow)
+			if err != nil {
+				return err
+			}
+			offset, err := graphformatter.ParseOffset(*season)
+			if err != nil {
+				return err
+			}
+			err = response.DetectAnomalies(graphformatter.AnomalyOptions{
+				Method:    *anomalies,
+				Window:    window,
+				Threshold: *anomalyThreshold,
+				Season:    offset,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *forecast != "" {
+			err = response.Predict(graphformatter.ForecastOptions{
+				Method:  *forecast,
+				Horizon: *horizon,
+				Season:  *forecastSeason,
+				Level:   *level,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if *labelStyle != "" || len(layouts) > 0 {
+			language, err := graphformatter.LoadLocale(*locale)
+			if err != nil {
+				return err
+			}
+			if err := response.Label(graphformatter.Labeler{Locale: language, Style: *labelStyle, Layouts: layouts}); err != nil {
+				return err
+			}
+		}
+
+		if *output == "terminal" {
+			return graphformatter.WriteBarChart(w, response.Points, loc, 60)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(response)
+	}
 
-	switch interval {
-	case "MONTH":
-		graphformatter.TimestampToUnixTime(
-			graphformatter.TimeDifferenceMonth(structs))
-	case "WEEK":
-		graphformatter.TimestampToUnixTime(
-			graphformatter.TimeDifferenceWeek(structs))
-	case "DAY":
-		graphformatter.TimestampToUnixTime(
-			graphformatter.TimeDifferenceDay(structs))
-	case "HOUR":
-		graphformatter.TimestampToUnixTime(
-			graphformatter.TimeDifferenceHour(structs))
-	}
-
-}
\ No newline at end of file
+	// render runs the whole pipeline on the transactions of one output.
+	var render renderFunc = func(structs []graphformatter.Transaction, groupBy []string, w io.Writer) error {
+		if predicate != nil {
+			structs = graphformatter.Filter(structs, predicate)
+		}
+		if *clamp != "" {
+			structs = graphformatter.Clamp(structs, clampMin, clampMax)
+		}
+
+		var quality *graphformatter.QualityReport
+		if validation != nil {
+			valid, r, err := graphformatter.Validate(structs, *validation)
+			for _, issue := range r.Issues {
+				log.Printf("%s: transaction %d: %s", issue.Kind, issue.Index+1, issue.Message)
+			}
+			if err != nil {
+				return err
+			}
+			log.Printf("validation: %d issues, dropped %d of %d transactions", len(r.Issues), r.Dropped, r.Input)
+			structs, quality = valid, &r
+		}
+
+		var report *graphformatter.DedupReport
+		if *dedup != "" {
+			deduped, r, err := graphformatter.Dedup(structs, graphformatter.DedupOptions{Key: *dedup, Tolerance: *dedupTolerance})
+			if err != nil {
+				return err
+			}
+			log.Printf("dedup: dropped %d of %d transactions", r.Dropped, r.Input)
+			structs, report = deduped, &r
+		}
+
+		switch counterMode {
+		case graphformatter.CounterIncrease, graphformatter.CounterRate:
+			structs = graphformatter.Increase(structs)
+		case graphformatter.CounterDelta:
+			structs = graphformatter.Delta(structs)
+		}
+
+		if *histogram != "" {
+			return writeHistogram(w, structs, opts, *histogram, *output)
+		}
+		if *profile {
+			return writeProfile(w, structs, opts, *normalize, *output)
+		}
+		if *calendar {
+			return writeCalendar(w, structs, opts, *weekStart, *output)
+		}
+		if *output != "json" && *output != "terminal" {
+			return fmt.Errorf("%s output is not available for buckets", *output)
+		}
+
+		response, err := graphformatter.NewFormatResponse(structs, opts, groupBy)
+		if err != nil {
+			return err
+		}
+		response.Dedup = report
+		response.Quality = quality
+		return finish(response, w)
+	}
+
+	patterns := flags.Args()
+	if *input != "" {
+		patterns = append([]string{*input}, patterns...)
+	}
+	if len(patterns) == 0 {
+		structs := []graphformatter.Transaction{}
+		return render(graphformatter.SliceSorter(graphformatter.SliceFiller(structs, graph)), nil, os.Stdout)
+	}
+	paths := expandInputs(patterns)
+	if *follow {
+		switch {
+		case len(paths) != 1:
+			return fmt.Errorf("watch mode follows a single input, not %d", len(paths))
+		case counterMode != "":
+			return errors.New("watch mode doesn't support counters")
+		case *dedup != "" || *validate != "":
+			return errors.New("watch mode doesn't support deduplication and validation")
+		case *histogram != "" || *profile || *calendar:
+			return errors.New("watch mode only draws buckets")
+		case *clamp != "":
+			return errors.New("watch mode doesn't support clamping")
+		case *compare != "" || *anomalies != "" || *forecast != "":
+			return errors.New("watch mode doesn't support comparisons, anomalies and forecasts")
+		case *output != "json" && *output != "terminal":
+			return fmt.Errorf("%s output is not available for buckets", *output)
+		}
+		return watch(paths[0], opts, predicate, *lateness, *poll, *output == "terminal", finish)
+	}
+	if *outputDir != "" {
+		return writeEach(paths, *jobs, *outputDir, *output, render)
+	}
+	if len(paths) > 1 && !*merge && (*histogram != "" || *profile || *calendar) {
+		return errors.New("histograms, profiles and calendars of several inputs need -merge or -output-dir")
+	}
+	return writeCombined(paths, *jobs, *merge, render)
+}
+
+// parseTime reads an RFC 3339 timestamp or a date in loc. An empty string
+// is the zero time.
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or a date", s)
+	}
+	return t, nil
+}
+
+func writeHistogram(w io.Writer, structs []graphformatter.Transaction, opts graphformatter.Options, bins, output string) error {
+	edges, err := graphformatter.ParseBins(bins, structs)
+	if err != nil {
+		return err
+	}
+	histogram, err := graphformatter.NewHistogram(structs, opts, edges)
+	if err != nil {
+		return err
+	}
+	switch output {
+	case "csv":
+		return histogram.WriteCSV(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(histogram)
+	}
+	return fmt.Errorf("unknown output format %q", output)
+}
+
+func writeProfile(w io.Writer, structs []graphformatter.Transaction, opts graphformatter.Options, normalize bool, output string) error {
+	profile, err := graphformatter.NewProfile(structs, opts, normalize)
+	if err != nil {
+		return err
+	}
+	switch output {
+	case "terminal":
+		return profile.WriteTerminal(w)
+	case "svg":
+		return profile.WriteSVG(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(profile)
+	}
+	return fmt.Errorf("unknown output format %q", output)
+}
+
+func writeCalendar(w io.Writer, structs []graphformatter.Transaction, opts graphformatter.Options, weekStart, output string) error {
+	calendarOpts, err := graphformatter.ParseWeekStart(weekStart)
+	if err != nil {
+		return err
+	}
+	opts.Interval = graphformatter.IntervalDay
+	points, err := graphformatter.Format(structs, opts)
+	if err != nil {
+		return err
+	}
+	calendar := graphformatter.NewCalendar(points, opts.Location, calendarOpts)
+	switch output {
+	case "terminal":
+		return calendar.WriteTerminal(w)
+	case "svg":
+		return calendar.WriteSVG(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(calendar)
+	}
+	return fmt.Errorf("unknown output format %q", output)
+}
@@ -0,0 +1,178 @@
+package graphformatter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	AnomalyZScore   = "ZSCORE"
+	AnomalyMAD      = "MAD"
+	AnomalySeasonal = "SEASONAL"
+)
+
+// DefaultAnomalyThreshold is the score from which a bucket is flagged.
+const DefaultAnomalyThreshold = 3
+
+// minBaseline is the number of earlier buckets a baseline needs.
+const minBaseline = 3
+
+// AnomalyOptions configure DetectAnomalies. Window is the trailing
+// baseline, 24 buckets if unset. Season is the distance to the bucket a
+// SEASONAL baseline compares with, a week if unset.
+type AnomalyOptions struct {
+	Method    string
+	Window    Window
+	Threshold float64
+	Season    Offset
+}
+
+// Anomaly annotates a bucket whose value deviates from its baseline by
+// Score scale units.
+type Anomaly struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+	Baseline  float64 `json:"baseline"`
+	Score     float64 `json:"score"`
+}
+
+// DetectAnomalies flags the points that deviate from a baseline computed
+// over the trailing window before them. ZSCORE scores against mean and
+// standard deviation, MAD against median and median absolute deviation.
+// SEASONAL first subtracts the value one season earlier, e.g. the same
+// hour last week, and scores the differences like MAD. Points whose
+// baseline has fewer than three buckets are not scored. A baseline
+// without any variation is given a scale of one, the resolution of the
+// integer transaction values, so that deviations from it still stand out.
+func DetectAnomalies(points []Point, opts AnomalyOptions, loc *time.Location) ([]Anomaly, error) {
+	method := strings.ToUpper(opts.Method)
+	switch method {
+	case AnomalyZScore, AnomalyMAD, AnomalySeasonal:
+	default:
+		return nil, fmt.Errorf("unknown anomaly method %q", opts.Method)
+	}
+	if opts.Window == (Window{}) {
+		opts.Window = Window{Buckets: 24}
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultAnomalyThreshold
+	}
+	if opts.Season == (Offset{}) {
+		opts.Season = Offset{Days: 7}
+	}
+
+	series := points
+	seasonal := make([]float64, len(points))
+	if method == AnomalySeasonal {
+		series, seasonal = seasonalDifferences(points, opts.Season, loc)
+	}
+
+	result := []Anomaly{}
+	for i, p := range series {
+		lo := trailingStart(series, i, opts.Window)
+		if i-lo < minBaseline {
+			continue
+		}
+		baseline := make([]float64, 0, i-lo)
+		for _, q := range series[lo:i] {
+			baseline = append(baseline, q.Value)
+		}
+
+		var center, scale float64
+		if method == AnomalyZScore {
+			center, scale = meanAndDeviation(baseline)
+		} else {
+			center, scale = medianAndDeviation(baseline)
+		}
+		if scale == 0 {
+			scale = 1
+		}
+		score := (p.Value - center) / scale
+		if math.Abs(score) >= opts.Threshold {
+			result = append(result, Anomaly{
+				Timestamp: p.Timestamp,
+				Value:     p.Value + seasonal[i],
+				Baseline:  center + seasonal[i],
+				Score:     score,
+			})
+		}
+	}
+	return result, nil
+}
+
+// seasonalDifferences returns the points that have a value one season
+// earlier, with that value subtracted, along with the subtracted values.
+func seasonalDifferences(points []Point, season Offset, loc *time.Location) ([]Point, []float64) {
+	values := make(map[int64]float64, len(points))
+	for _, p := range points {
+		values[p.Timestamp] = p.Value
+	}
+	differences := []Point{}
+	previous := []float64{}
+	for _, p := range points {
+		earlier, ok := values[season.Before(time.Unix(p.Timestamp, 0), loc).Unix()]
+		if !ok {
+			continue
+		}
+		differences = append(differences, Point{Timestamp: p.Timestamp, Value: p.Value - earlier})
+		previous = append(previous, earlier)
+	}
+	return differences, previous
+}
+
+// trailingStart returns the first index of the window that ends right
+// before points[i].
+func trailingStart(points []Point, i int, window Window) int {
+	if window.Duration > 0 {
+		from := points[i].Timestamp - durationSeconds(window.Duration)
+		lo := i
+		for lo > 0 && points[lo-1].Timestamp >= from {
+			lo--
+		}
+		return lo
+	}
+	return max(0, i-window.Buckets)
+}
+
+func meanAndDeviation(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// medianAndDeviation returns the median and the median absolute deviation
+// scaled to be comparable with a standard deviation. If more than half of
+// the values are equal, the mean absolute deviation is used instead.
+func medianAndDeviation(values []float64) (float64, float64) {
+	center := median(values)
+	deviations := make([]float64, len(values))
+	meanDeviation := 0.0
+	for i, v := range values {
+		deviations[i] = math.Abs(v - center)
+		meanDeviation += deviations[i]
+	}
+	if mad := median(deviations); mad > 0 {
+		return center, 1.4826 * mad
+	}
+	return center, 1.2533 * meanDeviation / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
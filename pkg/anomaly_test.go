@@ -0,0 +1,88 @@
+package graphformatter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Three weeks of hourly data with a daily pattern: busy from 9 to 17.
+	points := []Point{}
+	for h := 0; h < 3*7*24; h++ {
+		ts := start.Add(time.Duration(h) * time.Hour)
+		value := 10.0 + float64(h%3)
+		if ts.Hour() >= 9 && ts.Hour() < 17 {
+			value += 100
+		}
+		points = append(points, Point{Timestamp: ts.Unix(), Value: value})
+	}
+	// A busy hour in the middle of the night in the third week.
+	spike := 2*7*24 + 3*24 + 2
+	points[spike].Value += 100
+
+	tests := []struct {
+		name string
+		opts AnomalyOptions
+	}{
+		{name: "Z-score", opts: AnomalyOptions{Method: AnomalyZScore, Window: Window{Buckets: 6}}},
+		{name: "MAD", opts: AnomalyOptions{Method: AnomalyMAD, Window: Window{Duration: 6 * time.Hour}}},
+		{name: "Seasonal", opts: AnomalyOptions{Method: AnomalySeasonal, Window: Window{Buckets: 48}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DetectAnomalies(points, tt.opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, a := range result {
+				found = found || a.Timestamp == points[spike].Timestamp
+			}
+			if !found {
+				t.Errorf("DetectAnomalies() = %v, want the spike at %d flagged", result, points[spike].Timestamp)
+			}
+		})
+	}
+}
+
+func TestDetectAnomaliesSeasonal(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	points := []Point{}
+	for h := 0; h < 3*7*24; h++ {
+		ts := start.Add(time.Duration(h) * time.Hour)
+		value := 10.0 + float64(h%5)
+		if ts.Hour() >= 9 && ts.Hour() < 17 {
+			value += 100
+		}
+		points = append(points, Point{Timestamp: ts.Unix(), Value: value})
+	}
+
+	result, err := DetectAnomalies(points, AnomalyOptions{Method: AnomalySeasonal, Window: Window{Buckets: 48}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Errorf("DetectAnomalies() flagged the regular daily pattern: %v", result)
+	}
+
+	// Any offset is a season, such as a week given as a duration.
+	for _, season := range []string{"WEEK", "168h"} {
+		offset, err := ParseOffset(season)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := DetectAnomalies(points, AnomalyOptions{Method: AnomalySeasonal, Window: Window{Buckets: 48}, Season: offset}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 0 {
+			t.Errorf("DetectAnomalies() with season %s flagged the regular daily pattern: %v", season, result)
+		}
+	}
+
+	if _, err := DetectAnomalies(points, AnomalyOptions{Method: "PROPHET"}, nil); err == nil {
+		t.Errorf("DetectAnomalies() error = nil, want error")
+	}
+}
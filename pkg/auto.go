@@ -0,0 +1,141 @@
+package graphformatter
+
+import (
+	"strconv"
+	"time"
+)
+
+// DefaultMaxPoints is the number of buckets the AUTO interval aims for
+// when Options.MaxPoints is not set.
+const DefaultMaxPoints = 500
+
+// NiceIntervals are the candidates of the AUTO interval, from the finest
+// to the coarsest.
+var NiceIntervals = []string{"1m", "5m", "15m", IntervalHour, "6h", IntervalDay, IntervalWeek, IntervalMonth}
+
+// AutoInterval returns the finest of NiceIntervals that splits the range
+// from..to into at most maxPoints buckets in loc, or the coarsest one if
+// none does.
+func AutoInterval(from, to time.Time, maxPoints int, loc *time.Location) string {
+	if maxPoints < 1 {
+		maxPoints = DefaultMaxPoints
+	}
+	for _, interval := range NiceIntervals {
+		if countBuckets(from, to, interval, loc) <= maxPoints {
+			return interval
+		}
+	}
+	return NiceIntervals[len(NiceIntervals)-1]
+}
+
+// countBuckets counts the buckets between from and to. It computes the
+// count from the distance in wall clock time in loc instead of stepping
+// through the buckets, so that its cost doesn't depend on the range.
+func countBuckets(from, to time.Time, interval string, loc *time.Location) int {
+	start, end := BucketStart(from, interval, loc), BucketStart(to, interval, loc)
+	if end.Before(start) {
+		return 1
+	}
+	wall := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	}
+	days := int(wall(end).Truncate(24*time.Hour).Sub(wall(start).Truncate(24*time.Hour)) / (24 * time.Hour))
+	switch canonicalInterval(interval) {
+	case IntervalMonth:
+		return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+	case IntervalWeek:
+		return days/7 + 1
+	case IntervalDay:
+		return days + 1
+	case IntervalHour:
+		return int(end.Sub(start)/time.Hour) + 1
+	}
+	unit, n, _ := intervalStep(interval)
+	if unit == time.Hour {
+		return int(wall(end).Sub(wall(start))/(time.Duration(n)*time.Hour)) + 1
+	}
+	return int(end.Sub(start)/(time.Duration(n)*time.Minute)) + 1
+}
+
+// Resolve replaces the AUTO interval with the one AutoInterval picks for
+// the time range of structs. Other intervals are returned unchanged.
+func (o Options) Resolve(structs []Transaction) Options {
+	if o.Interval != IntervalAuto {
+		return o
+	}
+	if len(structs) == 0 {
+		o.Interval = NiceIntervals[0]
+		return o
+	}
+	from, to := structs[0].Timestamp, structs[0].Timestamp
+	for _, tx := range structs {
+		if tx.Timestamp.Before(from) {
+			from = tx.Timestamp
+		}
+		if tx.Timestamp.After(to) {
+			to = tx.Timestamp
+		}
+	}
+	o.Interval = AutoInterval(from, to, o.MaxPoints, o.location())
+	return o
+}
+
+// canonicalInterval maps the short names of the named intervals to them.
+func canonicalInterval(interval string) string {
+	switch interval {
+	case "1h":
+		return IntervalHour
+	case "1d":
+		return IntervalDay
+	case "1w":
+		return IntervalWeek
+	case "1M":
+		return IntervalMonth
+	}
+	return interval
+}
+
+func validInterval(interval string) bool {
+	switch canonicalInterval(interval) {
+	case IntervalMonth, IntervalWeek, IntervalDay, IntervalHour:
+		return true
+	}
+	_, _, ok := intervalStep(interval)
+	return ok
+}
+
+// intervalStep reads intervals of n minutes ("5m") or n hours ("6h"). The
+// steps have to divide an hour or a day so that buckets stay aligned.
+func intervalStep(interval string) (time.Duration, int, bool) {
+	if len(interval) < 2 {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n < 1 {
+		return 0, 0, false
+	}
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Minute, n, 60%n == 0
+	case 'h':
+		return time.Hour, n, 24%n == 0
+	}
+	return 0, 0, false
+}
+
+// intervalLength is the nominal length of an interval, used to order
+// intervals by coarseness.
+func intervalLength(interval string) time.Duration {
+	switch canonicalInterval(interval) {
+	case IntervalMonth:
+		return 30 * 24 * time.Hour
+	case IntervalWeek:
+		return 7 * 24 * time.Hour
+	case IntervalDay:
+		return 24 * time.Hour
+	case IntervalHour:
+		return time.Hour
+	}
+	unit, n, _ := intervalStep(interval)
+	return time.Duration(n) * unit
+}
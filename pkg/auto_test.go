@@ -0,0 +1,116 @@
+package graphformatter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoInterval(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		to        time.Time
+		maxPoints int
+		expected  string
+	}{
+		{name: "Hour by minutes", to: from.Add(time.Hour), maxPoints: 100, expected: "1m"},
+		{name: "Day by quarter hours", to: from.Add(24 * time.Hour), maxPoints: 100, expected: "15m"},
+		{name: "Week by hours", to: from.AddDate(0, 0, 7), maxPoints: 200, expected: IntervalHour},
+		{name: "Month by six hours", to: from.AddDate(0, 1, 0), maxPoints: 200, expected: "6h"},
+		{name: "Year by days", to: from.AddDate(1, 0, 0), maxPoints: 400, expected: IntervalDay},
+		{name: "Year by weeks", to: from.AddDate(1, 0, 0), maxPoints: 100, expected: IntervalWeek},
+		{name: "Decade by months", to: from.AddDate(10, 0, 0), maxPoints: 200, expected: IntervalMonth},
+		{name: "Coarsest if nothing fits", to: from.AddDate(10, 0, 0), maxPoints: 10, expected: IntervalMonth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := AutoInterval(from, tt.to, tt.maxPoints, nil); result != tt.expected {
+				t.Errorf("AutoInterval() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountBuckets(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both ranges cross a DST change in Berlin.
+	ranges := [][2]time.Time{
+		{time.Date(2023, 3, 10, 17, 3, 0, 0, berlin), time.Date(2023, 4, 20, 5, 59, 0, 0, berlin)},
+		{time.Date(2023, 10, 1, 0, 0, 0, 0, berlin), time.Date(2023, 11, 30, 23, 0, 0, 0, berlin)},
+	}
+	for _, r := range ranges {
+		for _, interval := range append([]string{"2h", "30m"}, NiceIntervals...) {
+			expected := 1
+			end := BucketStart(r[1], interval, berlin)
+			for start := BucketStart(r[0], interval, berlin); start.Before(end); start = NextBucketStart(start, interval) {
+				expected++
+			}
+			if result := countBuckets(r[0], r[1], interval, berlin); result != expected {
+				t.Errorf("countBuckets(%v, %v, %s) = %d, want %d", r[0], r[1], interval, result, expected)
+			}
+		}
+	}
+
+	// Counting doesn't step through the buckets, so huge ranges are cheap.
+	from, to := time.Unix(0, 0), time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+	if result := AutoInterval(from, to, 2000000000, nil); result != "1m" {
+		t.Errorf("AutoInterval() = %s, want 1m", result)
+	}
+}
+
+func TestBucketStartSteps(t *testing.T) {
+	input := time.Date(2023, 1, 15, 13, 47, 12, 0, time.UTC)
+	tests := []struct {
+		interval string
+		expected time.Time
+		next     time.Time
+	}{
+		{"1m", time.Date(2023, 1, 15, 13, 47, 0, 0, time.UTC), time.Date(2023, 1, 15, 13, 48, 0, 0, time.UTC)},
+		{"15m", time.Date(2023, 1, 15, 13, 45, 0, 0, time.UTC), time.Date(2023, 1, 15, 14, 0, 0, 0, time.UTC)},
+		{"6h", time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC), time.Date(2023, 1, 15, 18, 0, 0, 0, time.UTC)},
+		{"1d", time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"1M", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			start := BucketStart(input, tt.interval, nil)
+			if !start.Equal(tt.expected) {
+				t.Errorf("BucketStart() = %v, want %v", start, tt.expected)
+			}
+			if next := NextBucketStart(start, tt.interval); !next.Equal(tt.next) {
+				t.Errorf("NextBucketStart() = %v, want %v", next, tt.next)
+			}
+		})
+	}
+
+	for _, interval := range []string{"7m", "5h", "0m", "m", "5s"} {
+		if err := (Options{Interval: interval}).Validate(); err == nil {
+			t.Errorf("Validate() of %s: error = nil, want error", interval)
+		}
+	}
+}
+
+func TestFormatAuto(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	structs := []Transaction{
+		{Value: 1, Timestamp: start},
+		{Value: 2, Timestamp: start.Add(20 * time.Hour)},
+	}
+
+	response, err := NewFormatResponse(structs, Options{Interval: IntervalAuto, MaxPoints: 30}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Interval != IntervalHour || len(response.Points) != 2 {
+		t.Errorf("NewFormatResponse() = %v, want two HOUR buckets", response)
+	}
+
+	if _, err := NewStream(Options{Interval: IntervalAuto}, 0); err == nil {
+		t.Errorf("NewStream() with AUTO: error = nil, want error")
+	}
+}
@@ -0,0 +1,285 @@
+package graphformatter
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	IntervalMonth = "MONTH"
+	IntervalWeek  = "WEEK"
+	IntervalDay   = "DAY"
+	IntervalHour  = "HOUR"
+	IntervalAuto  = "AUTO"
+)
+
+const (
+	AggregationSum   = "SUM"
+	AggregationCount = "COUNT"
+	AggregationAvg   = "AVG"
+	AggregationMin   = "MIN"
+	AggregationMax   = "MAX"
+)
+
+// Options describes how transactions are grouped into buckets.
+// A nil Location means UTC, an empty Aggregation means SUM. Workers above
+// one aggregate partitions of the input concurrently. With the AUTO
+// interval, MaxPoints bounds the number of buckets, DefaultMaxPoints if
+// zero.
+type Options struct {
+	Interval    string
+	Location    *time.Location
+	Aggregation string
+	Workers     int
+	MaxPoints   int
+}
+
+// Bucket holds the partial aggregates of all transactions that fall into
+// the interval starting at Start. Sketch is only collected for quantile
+// aggregations.
+type Bucket struct {
+	Start  time.Time
+	Count  int
+	Sum    int
+	Min    int
+	Max    int
+	Sketch *Sketch
+}
+
+// Point is a single value of a bucketed series. Label is only set by
+// LabelPoints.
+type Point struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+	Label     string  `json:"label,omitempty"`
+}
+
+func (o Options) Validate() error {
+	if o.Interval != IntervalAuto && !validInterval(o.Interval) {
+		return fmt.Errorf("unknown interval %q", o.Interval)
+	}
+	switch o.Aggregation {
+	case "", AggregationSum, AggregationCount, AggregationAvg, AggregationMin, AggregationMax:
+	default:
+		if _, ok := parseQuantile(o.Aggregation); !ok {
+			return fmt.Errorf("unknown aggregation %q", o.Aggregation)
+		}
+	}
+	return nil
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+func (o Options) aggregation() string {
+	if o.Aggregation == "" {
+		return AggregationSum
+	}
+	return o.Aggregation
+}
+
+func (o Options) newBucket(start time.Time) Bucket {
+	b := Bucket{Start: start}
+	if _, ok := parseQuantile(o.Aggregation); ok {
+		b.Sketch = NewSketch()
+	}
+	return b
+}
+
+// BucketStart returns the beginning of the interval containing t, computed
+// in loc. Weeks start on Monday as in ISO 8601; steps of minutes or hours
+// are aligned to the full hour or day.
+func BucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	switch canonicalInterval(interval) {
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	case IntervalDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case IntervalHour:
+		return t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond()))
+	}
+	if unit, n, ok := intervalStep(interval); ok {
+		if unit == time.Hour {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()-t.Hour()%n, 0, 0, 0, loc)
+		}
+		return t.Add(-time.Duration(t.Minute()%n)*time.Minute -
+			time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond()))
+	}
+	return t
+}
+
+// NextBucketStart returns the beginning of the interval that follows the
+// one starting at start.
+func NextBucketStart(start time.Time, interval string) time.Time {
+	switch canonicalInterval(interval) {
+	case IntervalMonth:
+		return time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, start.Location())
+	case IntervalWeek:
+		return time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, start.Location())
+	case IntervalDay:
+		return time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+	case IntervalHour:
+		return start.Add(time.Hour)
+	}
+	if unit, n, ok := intervalStep(interval); ok {
+		if unit == time.Hour {
+			return time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+n, 0, 0, 0, start.Location())
+		}
+		return start.Add(time.Duration(n) * time.Minute)
+	}
+	return start
+}
+
+// Aggregate groups transactions into buckets of opts.Interval and returns
+// the non-empty buckets in ascending order. The input slice is not modified.
+func Aggregate(structs []Transaction, opts Options) ([]Bucket, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.Resolve(structs)
+	if opts.Workers > 1 {
+		return aggregateParallel(structs, opts), nil
+	}
+	return aggregateSorted(ascending(structs), opts), nil
+}
+
+func aggregateSorted(sorted []Transaction, opts Options) []Bucket {
+	result := []Bucket{}
+	loc := opts.location()
+	var end time.Time
+	for _, tx := range sorted {
+		if len(result) == 0 || !tx.Timestamp.Before(end) {
+			start := BucketStart(tx.Timestamp, opts.Interval, loc)
+			end = NextBucketStart(start, opts.Interval)
+			result = append(result, opts.newBucket(start))
+		}
+		result[len(result)-1].add(tx.Value)
+	}
+	return result
+}
+
+// ascending returns structs ordered from oldest to newest, keeping
+// transactions with the same timestamp in input order. Input that is
+// already sorted in either direction, like the output of SliceSorter, is
+// returned or reversed in linear time; anything else is sorted in a copy.
+func ascending(structs []Transaction) []Transaction {
+	if sort.SliceIsSorted(structs, func(i, j int) bool {
+		return structs[i].Timestamp.Before(structs[j].Timestamp)
+	}) {
+		return structs
+	}
+	sorted := make([]Transaction, len(structs))
+	if sort.SliceIsSorted(structs, func(i, j int) bool {
+		return structs[i].Timestamp.After(structs[j].Timestamp)
+	}) {
+		// Reverse the runs of equal timestamps, not the transactions
+		// within them.
+		sorted = sorted[:0]
+		for end := len(structs); end > 0; {
+			start := end - 1
+			for start > 0 && structs[start-1].Timestamp.Equal(structs[end-1].Timestamp) {
+				start--
+			}
+			sorted = append(sorted, structs[start:end]...)
+			end = start
+		}
+		return sorted
+	}
+	copy(sorted, structs)
+	slices.SortStableFunc(sorted, func(a, b Transaction) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return sorted
+}
+
+func (b *Bucket) add(value int) {
+	if b.Count == 0 || value < b.Min {
+		b.Min = value
+	}
+	if b.Count == 0 || value > b.Max {
+		b.Max = value
+	}
+	b.Count++
+	b.Sum += value
+	if b.Sketch != nil {
+		b.Sketch.Add(value)
+	}
+}
+
+func (b *Bucket) merge(o Bucket) {
+	if o.Count == 0 {
+		return
+	}
+	if b.Count == 0 || o.Min < b.Min {
+		b.Min = o.Min
+	}
+	if b.Count == 0 || o.Max > b.Max {
+		b.Max = o.Max
+	}
+	b.Count += o.Count
+	b.Sum += o.Sum
+	if o.Sketch != nil {
+		if b.Sketch == nil {
+			b.Sketch = NewSketch()
+		}
+		b.Sketch.Merge(o.Sketch)
+	}
+}
+
+// Value returns the bucket aggregated with aggregation.
+func (b Bucket) Value(aggregation string) float64 {
+	switch aggregation {
+	case AggregationCount:
+		return float64(b.Count)
+	case AggregationAvg:
+		if b.Count == 0 {
+			return 0
+		}
+		return float64(b.Sum) / float64(b.Count)
+	case AggregationMin:
+		return float64(b.Min)
+	case AggregationMax:
+		return float64(b.Max)
+	}
+	if q, ok := parseQuantile(aggregation); ok {
+		if b.Sketch == nil {
+			return 0
+		}
+		return b.Sketch.Quantile(q)
+	}
+	return float64(b.Sum)
+}
+
+// Points converts buckets to a series of Unix timestamps and values.
+func Points(buckets []Bucket, aggregation string) []Point {
+	result := make([]Point, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, Point{Timestamp: b.Start.Unix(), Value: b.Value(aggregation)})
+	}
+	return result
+}
+
+// Format aggregates transactions and returns the resulting series.
+func Format(structs []Transaction, opts Options) ([]Point, error) {
+	buckets, err := Aggregate(structs, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Points(buckets, opts.aggregation()), nil
+}
@@ -0,0 +1,160 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBucketStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		input    time.Time
+		interval string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "Month",
+			input:    time.Date(2023, 3, 15, 12, 30, 0, 0, time.UTC),
+			interval: IntervalMonth,
+			expected: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Week starts on Monday",
+			input:    time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC),
+			interval: IntervalWeek,
+			expected: time.Date(2023, 1, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Day",
+			input:    time.Date(2023, 1, 15, 23, 59, 0, 0, time.UTC),
+			interval: IntervalDay,
+			expected: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Hour",
+			input:    time.Date(2023, 1, 15, 23, 59, 0, 0, time.UTC),
+			interval: IntervalHour,
+			expected: time.Date(2023, 1, 15, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Day in another timezone",
+			input:    time.Date(2023, 1, 15, 23, 30, 0, 0, time.UTC),
+			interval: IntervalDay,
+			loc:      berlin,
+			expected: time.Date(2023, 1, 16, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BucketStart(tt.input, tt.interval, tt.loc)
+			if !result.Equal(tt.expected) {
+				t.Errorf("BucketStart() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAscending(t *testing.T) {
+	at := func(seconds int64) time.Time {
+		return time.Unix(seconds, 0).UTC()
+	}
+	tests := []struct {
+		name     string
+		input    []Transaction
+		expected []int
+	}{
+		{name: "Ascending", input: []Transaction{{Value: 0, Timestamp: at(1)}, {Value: 1, Timestamp: at(2)}, {Value: 2, Timestamp: at(2)}}, expected: []int{0, 1, 2}},
+		{name: "Descending", input: []Transaction{{Value: 0, Timestamp: at(3)}, {Value: 1, Timestamp: at(2)}, {Value: 2, Timestamp: at(2)}, {Value: 3, Timestamp: at(1)}}, expected: []int{3, 1, 2, 0}},
+		{name: "Unsorted", input: []Transaction{{Value: 0, Timestamp: at(2)}, {Value: 1, Timestamp: at(1)}, {Value: 2, Timestamp: at(3)}, {Value: 3, Timestamp: at(2)}, {Value: 4, Timestamp: at(1)}}, expected: []int{1, 4, 0, 3, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := []int{}
+			for _, tx := range ascending(tt.input) {
+				result = append(result, tx.Value)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ascending() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	input := []Transaction{
+		{Value: 3, Timestamp: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{Value: 1, Timestamp: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{Value: 5, Timestamp: time.Date(2023, 1, 1, 13, 0, 0, 0, time.UTC)},
+	}
+	expected := []Bucket{
+		{Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Count: 2, Sum: 6, Min: 1, Max: 5},
+		{Start: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Count: 1, Sum: 3, Min: 3, Max: 3},
+	}
+
+	result, err := Aggregate(input, Options{Interval: IntervalDay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Aggregate() = %v, want %v", result, expected)
+	}
+	if input[0].Value != 3 {
+		t.Errorf("Aggregate() modified its input")
+	}
+}
+
+func TestAggregateInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "Unknown interval", opts: Options{Interval: "YEAR"}},
+		{name: "Unknown aggregation", opts: Options{Interval: IntervalDay, Aggregation: "MEDIAN"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Aggregate(nil, tt.opts); err == nil {
+				t.Errorf("Aggregate() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	input := []Transaction{
+		{Value: 2, Timestamp: time.Date(2023, 1, 1, 12, 10, 0, 0, time.UTC)},
+		{Value: 4, Timestamp: time.Date(2023, 1, 1, 12, 50, 0, 0, time.UTC)},
+		{Value: 9, Timestamp: time.Date(2023, 1, 1, 14, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		aggregation string
+		expected    []Point
+	}{
+		{AggregationSum, []Point{{Timestamp: 1672574400, Value: 6}, {Timestamp: 1672581600, Value: 9}}},
+		{AggregationCount, []Point{{Timestamp: 1672574400, Value: 2}, {Timestamp: 1672581600, Value: 1}}},
+		{AggregationAvg, []Point{{Timestamp: 1672574400, Value: 3}, {Timestamp: 1672581600, Value: 9}}},
+		{AggregationMin, []Point{{Timestamp: 1672574400, Value: 2}, {Timestamp: 1672581600, Value: 9}}},
+		{AggregationMax, []Point{{Timestamp: 1672574400, Value: 4}, {Timestamp: 1672581600, Value: 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregation, func(t *testing.T) {
+			result, err := Format(input, Options{Interval: IntervalHour, Aggregation: tt.aggregation})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Format() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
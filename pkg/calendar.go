@@ -0,0 +1,247 @@
+package graphformatter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CalendarOptions configure NewCalendar. Weeks start on WeekStart and
+// every year runs from January 1 to December 31. With ISO, weeks start on
+// Monday and years follow ISO 8601, so the days around New Year belong to
+// the year of their week.
+type CalendarOptions struct {
+	WeekStart time.Weekday
+	ISO       bool
+}
+
+// Calendar lays out daily values as one grid of weeks by weekdays per
+// year, like a contribution calendar.
+type Calendar struct {
+	Timezone  string         `json:"timezone"`
+	WeekStart string         `json:"week_start"`
+	ISO       bool           `json:"iso"`
+	Years     []CalendarYear `json:"years"`
+
+	loc *time.Location
+}
+
+type CalendarYear struct {
+	Year  int            `json:"year"`
+	Weeks []CalendarWeek `json:"weeks"`
+}
+
+// CalendarWeek is a column of the grid starting at Start. Days outside the
+// year are nil, days of the year without data are zero. Week is the ISO
+// week number and only set for ISO calendars.
+type CalendarWeek struct {
+	Start int64       `json:"start"`
+	Week  int         `json:"week,omitempty"`
+	Days  [7]*float64 `json:"days"`
+}
+
+// ParseWeekStart reads ISO or the name of a weekday like SUNDAY.
+func ParseWeekStart(s string) (CalendarOptions, error) {
+	if strings.ToUpper(s) == "ISO" {
+		return CalendarOptions{WeekStart: time.Monday, ISO: true}, nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(s, day.String()) {
+			return CalendarOptions{WeekStart: day}, nil
+		}
+	}
+	return CalendarOptions{}, fmt.Errorf("unknown week start %q", s)
+}
+
+// NewCalendar lays out points of DAY buckets in loc, covering every year
+// from the first to the last point. Points on the same day are added up.
+func NewCalendar(points []Point, loc *time.Location, opts CalendarOptions) Calendar {
+	loc = locationOrUTC(loc)
+	if opts.ISO {
+		opts.WeekStart = time.Monday
+	}
+	calendar := Calendar{Timezone: loc.String(), WeekStart: opts.WeekStart.String(), ISO: opts.ISO, Years: []CalendarYear{}, loc: loc}
+	if len(points) == 0 {
+		return calendar
+	}
+
+	// Days are handled as UTC dates, so that DST changes in loc don't
+	// shift them.
+	values := map[time.Time]float64{}
+	first, last := 0, 0
+	for i, p := range points {
+		day := calendarDate(time.Unix(p.Timestamp, 0).In(loc))
+		values[day] += p.Value
+		year := calendarYear(day, opts.ISO)
+		if i == 0 || year < first {
+			first = year
+		}
+		if i == 0 || year > last {
+			last = year
+		}
+	}
+
+	for year := first; year <= last; year++ {
+		from, to := yearBounds(year, opts.ISO)
+		day := from.AddDate(0, 0, -((int(from.Weekday()) - int(opts.WeekStart) + 7) % 7))
+		cy := CalendarYear{Year: year, Weeks: []CalendarWeek{}}
+		for !day.After(to) {
+			week := CalendarWeek{Start: time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc).Unix()}
+			if opts.ISO {
+				_, week.Week = day.ISOWeek()
+			}
+			for i := range week.Days {
+				if !day.Before(from) && !day.After(to) {
+					value := values[day]
+					week.Days[i] = &value
+				}
+				day = day.AddDate(0, 0, 1)
+			}
+			cy.Weeks = append(cy.Weeks, week)
+		}
+		calendar.Years = append(calendar.Years, cy)
+	}
+	return calendar
+}
+
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func calendarYear(day time.Time, iso bool) int {
+	if iso {
+		year, _ := day.ISOWeek()
+		return year
+	}
+	return day.Year()
+}
+
+// yearBounds returns the first and last day of year. ISO years start on
+// the Monday of the week with January 4.
+func yearBounds(year int, iso bool) (time.Time, time.Time) {
+	if !iso {
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+	start := func(year int) time.Time {
+		jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.UTC)
+		return jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	}
+	return start(year), start(year+1).AddDate(0, 0, -1)
+}
+
+func (c Calendar) max() float64 {
+	max := 0.0
+	for _, year := range c.Years {
+		for _, week := range year.Weeks {
+			for _, v := range week.Days {
+				if v != nil && *v > max {
+					max = *v
+				}
+			}
+		}
+	}
+	return max
+}
+
+// weekday returns the weekday of a row of the grid of year.
+func (y CalendarYear) weekday(row int, loc *time.Location) time.Weekday {
+	return time.Unix(y.Weeks[0].Start, 0).In(loc).AddDate(0, 0, row).Weekday()
+}
+
+type monthLabel struct {
+	column int
+	name   string
+}
+
+// monthLabels returns the abbreviated month names placed at the week in
+// which each month begins, as long as they don't overlap.
+func (y CalendarYear) monthLabels(loc *time.Location) []monthLabel {
+	labels := []monthLabel{}
+	for i, week := range y.Weeks {
+		start := time.Unix(week.Start, 0).In(loc)
+		for d, v := range week.Days {
+			day := start.AddDate(0, 0, d)
+			if v == nil || day.Day() != 1 || (len(labels) > 0 && i < labels[len(labels)-1].column+4) {
+				continue
+			}
+			labels = append(labels, monthLabel{column: i, name: day.Month().String()[:3]})
+		}
+	}
+	return labels
+}
+
+// WriteTerminal draws every year with Unicode shade blocks, one column per
+// week. Days outside the year are left blank.
+func (c Calendar) WriteTerminal(w io.Writer) error {
+	var b strings.Builder
+	max, loc := c.max(), locationOrUTC(c.loc)
+	for i, year := range c.Years {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		header := []rune(strings.Repeat(" ", len(year.Weeks)+3))
+		for _, label := range year.monthLabels(loc) {
+			copy(header[label.column:], []rune(label.name))
+		}
+		fmt.Fprintf(&b, "%-4d %s\n", year.Year, strings.TrimRight(string(header), " "))
+		for row := 0; row < 7; row++ {
+			b.WriteString(year.weekday(row, loc).String()[:3] + "  ")
+			for _, week := range year.Weeks {
+				if v := week.Days[row]; v == nil {
+					b.WriteRune(' ')
+				} else {
+					b.WriteRune(shade(*v, max))
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSVG draws every year as an SVG heatmap below each other.
+func (c Calendar) WriteSVG(w io.Writer) error {
+	const size, left, top = 12, 32, 28
+	height := top + 7*size
+	weeks := 0
+	for _, year := range c.Years {
+		weeks = max(weeks, len(year.Weeks))
+	}
+	if _, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n",
+		left+weeks*size, height*len(c.Years)); err != nil {
+		return err
+	}
+	max, loc := c.max(), locationOrUTC(c.loc)
+	for i, year := range c.Years {
+		y := i*height + top
+		if err := svgText(w, 0, y-16, "start", fmt.Sprint(year.Year)); err != nil {
+			return err
+		}
+		for _, label := range year.monthLabels(loc) {
+			if err := svgText(w, left+label.column*size, y-4, "start", label.name); err != nil {
+				return err
+			}
+		}
+		for row := 0; row < 7; row += 2 {
+			if err := svgText(w, left-4, y+row*size+9, "end", year.weekday(row, loc).String()[:3]); err != nil {
+				return err
+			}
+		}
+		for column, week := range year.Weeks {
+			for row, v := range week.Days {
+				if v == nil {
+					continue
+				}
+				day := time.Unix(week.Start, 0).In(loc).AddDate(0, 0, row)
+				title := fmt.Sprintf("%s: %s", day.Format("Mon 2 Jan 2006"), formatFloat(*v))
+				if err := svgCell(w, left+column*size, y+row*size, size, *v, max, title); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, "</svg>\n")
+	return err
+}
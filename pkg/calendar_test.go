@@ -0,0 +1,106 @@
+package graphformatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWeekStart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected CalendarOptions
+		wantErr  bool
+	}{
+		{input: "ISO", expected: CalendarOptions{WeekStart: time.Monday, ISO: true}},
+		{input: "sunday", expected: CalendarOptions{WeekStart: time.Sunday}},
+		{input: "Saturday", expected: CalendarOptions{WeekStart: time.Saturday}},
+		{input: "SUN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseWeekStart(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWeekStart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseWeekStart() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewCalendar(t *testing.T) {
+	// Monday 30 December 2024 is in the first ISO week of 2025.
+	points := []Point{
+		{Timestamp: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC).Unix(), Value: 5},
+		{Timestamp: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC).Unix(), Value: 2},
+	}
+
+	t.Run("ISO", func(t *testing.T) {
+		calendar := NewCalendar(points, nil, CalendarOptions{ISO: true})
+		if len(calendar.Years) != 1 || calendar.Years[0].Year != 2025 || len(calendar.Years[0].Weeks) != 52 {
+			t.Fatalf("NewCalendar() = %+v, want the 52 weeks of 2025", calendar.Years)
+		}
+		week := calendar.Years[0].Weeks[0]
+		if week.Start != points[0].Timestamp || week.Week != 1 || *week.Days[0] != 7 || *week.Days[6] != 0 {
+			t.Errorf("NewCalendar() first week = %+v, want week 1 starting with 7", week)
+		}
+	})
+
+	t.Run("weeks from Sunday", func(t *testing.T) {
+		calendar := NewCalendar(points, nil, CalendarOptions{WeekStart: time.Sunday})
+		if len(calendar.Years) != 1 || calendar.Years[0].Year != 2024 || len(calendar.Years[0].Weeks) != 53 {
+			t.Fatalf("NewCalendar() = %+v, want the 53 weeks of 2024", calendar.Years)
+		}
+		weeks := calendar.Years[0].Weeks
+		if weeks[0].Days[0] != nil || weeks[0].Days[1] == nil || weeks[0].Week != 0 {
+			t.Errorf("NewCalendar() first week = %+v, want it to begin on Monday 1 January", weeks[0])
+		}
+		last := weeks[len(weeks)-1]
+		if *last.Days[1] != 7 || *last.Days[2] != 0 || last.Days[3] != nil {
+			t.Errorf("NewCalendar() last week = %+v, want it to end on Tuesday 31 December", last)
+		}
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		day := time.Date(2023, 6, 1, 0, 0, 0, 0, tokyo).Unix()
+		calendar := NewCalendar([]Point{{Timestamp: day, Value: 1}}, tokyo, CalendarOptions{ISO: true})
+		// Thursday 1 June 2023 is in week 22.
+		if week := calendar.Years[0].Weeks[21]; week.Week != 22 || *week.Days[3] != 1 {
+			t.Errorf("NewCalendar() week = %+v, want 1 on Thursday of week 22", week)
+		}
+	})
+}
+
+func TestCalendarRendering(t *testing.T) {
+	points := []Point{
+		{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), Value: 4},
+		{Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Unix(), Value: 1},
+	}
+	calendar := NewCalendar(points, nil, CalendarOptions{ISO: true})
+
+	var terminal bytes.Buffer
+	if err := calendar.WriteTerminal(&terminal); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(terminal.String(), "\n")
+	if len(lines) != 9 || !strings.HasPrefix(lines[0], "2024 Jan ") ||
+		!strings.HasPrefix(lines[1], "Mon  █ ") || !strings.HasPrefix(lines[2], "Tue  ░ ") {
+		t.Errorf("WriteTerminal() = %q", terminal.String())
+	}
+
+	var svg bytes.Buffer
+	if err := calendar.WriteSVG(&svg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg.String(), "<svg") || strings.Count(svg.String(), "<rect") != 364 {
+		t.Errorf("WriteSVG() = %q", svg.String())
+	}
+}
@@ -0,0 +1,98 @@
+package graphformatter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Offset is the distance between a period and the one it is compared
+// with. Calendar parts are applied in the timezone of the series, so a
+// week back is always the same weekday and hour, also across DST changes.
+type Offset struct {
+	Years    int
+	Months   int
+	Days     int
+	Duration time.Duration
+}
+
+// Comparison is a point of a series next to the point one offset earlier.
+// The previous value and the deltas are missing when the earlier bucket
+// is empty; the percentage is missing when the previous value is zero.
+type Comparison struct {
+	Timestamp         int64    `json:"timestamp"`
+	Value             float64  `json:"value"`
+	PreviousTimestamp int64    `json:"previous_timestamp"`
+	Previous          *float64 `json:"previous,omitempty"`
+	Delta             *float64 `json:"delta,omitempty"`
+	DeltaPercent      *float64 `json:"delta_percent,omitempty"`
+}
+
+// ParseOffset reads DAY, WEEK, MONTH, YEAR or a custom duration like "36h".
+func ParseOffset(s string) (Offset, error) {
+	switch strings.ToUpper(s) {
+	case "DAY":
+		return Offset{Days: 1}, nil
+	case "WEEK":
+		return Offset{Days: 7}, nil
+	case "MONTH":
+		return Offset{Months: 1}, nil
+	case "YEAR":
+		return Offset{Years: 1}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return Offset{}, fmt.Errorf("invalid offset %q", s)
+	}
+	return Offset{Duration: d}, nil
+}
+
+// Before returns t moved back by the offset in loc. Moving back by months
+// or years keeps the day within the target month, so March 31 goes back
+// to February 28 instead of overflowing into March.
+func (o Offset) Before(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	if o.Years != 0 || o.Months != 0 {
+		first := time.Date(t.Year()-o.Years, t.Month()-time.Month(o.Months), 1, 0, 0, 0, 0, loc)
+		day := min(t.Day(), daysIn(first))
+		t = time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	return t.AddDate(0, 0, -o.Days).Add(-o.Duration)
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// Compare aligns every point with the point one offset earlier and
+// returns the absolute and percentage change.
+func Compare(points []Point, offset Offset, loc *time.Location) []Comparison {
+	values := make(map[int64]float64, len(points))
+	for _, p := range points {
+		values[p.Timestamp] = p.Value
+	}
+
+	result := make([]Comparison, 0, len(points))
+	for _, p := range points {
+		c := Comparison{
+			Timestamp:         p.Timestamp,
+			Value:             p.Value,
+			PreviousTimestamp: offset.Before(time.Unix(p.Timestamp, 0), loc).Unix(),
+		}
+		if previous, ok := values[c.PreviousTimestamp]; ok {
+			delta := p.Value - previous
+			c.Previous = &previous
+			c.Delta = &delta
+			if previous != 0 {
+				percent := delta / math.Abs(previous) * 100
+				c.DeltaPercent = &percent
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
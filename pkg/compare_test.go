@@ -0,0 +1,119 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Offset
+		wantErr  bool
+	}{
+		{input: "day", expected: Offset{Days: 1}},
+		{input: "WEEK", expected: Offset{Days: 7}},
+		{input: "MONTH", expected: Offset{Months: 1}},
+		{input: "YEAR", expected: Offset{Years: 1}},
+		{input: "36h", expected: Offset{Duration: 36 * time.Hour}},
+		{input: "-1h", wantErr: true},
+		{input: "FORTNIGHT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseOffset(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOffset(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if result != tt.expected {
+			t.Errorf("ParseOffset(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestOffsetBefore(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		offset   string
+		input    time.Time
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "Day",
+			offset:   "DAY",
+			input:    time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 2, 28, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Week across DST",
+			offset:   "week",
+			input:    time.Date(2023, 3, 28, 10, 0, 0, 0, berlin),
+			loc:      berlin,
+			expected: time.Date(2023, 3, 21, 10, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Month clamps the day",
+			offset:   "MONTH",
+			input:    time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Month across the year",
+			offset:   "MONTH",
+			input:    time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2022, 12, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Year from a leap day",
+			offset:   "YEAR",
+			input:    time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Custom duration",
+			offset:   "36h",
+			input:    time.Date(2023, 3, 2, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, err := ParseOffset(tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := offset.Before(tt.input, tt.loc); !result.Equal(tt.expected) {
+				t.Errorf("Before() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ParseOffset("fortnight"); err == nil {
+		t.Errorf("ParseOffset() error = nil, want error")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	day := int64(24 * 60 * 60)
+	input := []Point{{Timestamp: 0, Value: 10}, {Timestamp: day, Value: 0}, {Timestamp: 7 * day, Value: 15}, {Timestamp: 8 * day, Value: 5}, {Timestamp: 9 * day, Value: 1}}
+	float := func(v float64) *float64 { return &v }
+	expected := []Comparison{
+		{Timestamp: 0, Value: 10, PreviousTimestamp: -7 * day},
+		{Timestamp: day, Value: 0, PreviousTimestamp: -6 * day},
+		{Timestamp: 7 * day, Value: 15, PreviousTimestamp: 0, Previous: float(10), Delta: float(5), DeltaPercent: float(50)},
+		{Timestamp: 8 * day, Value: 5, PreviousTimestamp: day, Previous: float(0), Delta: float(5)},
+		{Timestamp: 9 * day, Value: 1, PreviousTimestamp: 2 * day},
+	}
+
+	result := Compare(input, Offset{Days: 7}, time.UTC)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Compare() = %v, want %v", result, expected)
+	}
+}
@@ -0,0 +1,88 @@
+package graphformatter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CounterIncrease = "INCREASE"
+	CounterRate     = "RATE"
+	CounterDelta    = "DELTA"
+)
+
+// ParseCounter reads a counter mode. INCREASE and RATE sum the increases
+// per bucket, so they only go with the SUM aggregation.
+func ParseCounter(mode, aggregation string) (string, error) {
+	mode = strings.ToUpper(mode)
+	switch mode {
+	case "", CounterDelta:
+	case CounterIncrease, CounterRate:
+		if aggregation != "" && aggregation != AggregationSum {
+			return "", fmt.Errorf("counter mode %s needs the SUM aggregation, not %s", mode, aggregation)
+		}
+	default:
+		return "", fmt.Errorf("unknown counter mode %q", mode)
+	}
+	return mode, nil
+}
+
+// Increase treats the values of every label set as a monotonically
+// increasing counter and returns the increase since the previous sample,
+// at the time of the later one. Like Prometheus, a value lower than the
+// previous one is taken as a counter reset, so the increase is the new
+// value itself. Bucketing the result with SUM gives the increase per
+// bucket.
+func Increase(structs []Transaction) []Transaction {
+	return counterChanges(structs, func(previous, current int) int {
+		if current < previous {
+			return current
+		}
+		return current - previous
+	})
+}
+
+// Delta returns the difference to the previous sample of the same label
+// set without reset detection, for gauges that may go down.
+func Delta(structs []Transaction) []Transaction {
+	return counterChanges(structs, func(previous, current int) int {
+		return current - previous
+	})
+}
+
+func counterChanges(structs []Transaction, change func(previous, current int) int) []Transaction {
+	result := []Transaction{}
+	last := map[string]int{}
+	for _, tx := range ascending(structs) {
+		key := tx.Labels.String()
+		if previous, ok := last[key]; ok {
+			result = append(result, Transaction{
+				Value:     change(previous, tx.Value),
+				Timestamp: tx.Timestamp,
+				Labels:    tx.Labels,
+			})
+		}
+		last[key] = tx.Value
+	}
+	return result
+}
+
+// PerSecond divides the value of every bucket by its length in seconds,
+// turning the per-bucket increase into a rate. Bucket lengths follow the
+// calendar in loc, so months and DST days have their actual length.
+func PerSecond(points []Point, interval string, loc *time.Location) ([]Point, error) {
+	if interval == IntervalAuto || !validInterval(interval) {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	result := make([]Point, 0, len(points))
+	for _, p := range points {
+		start := time.Unix(p.Timestamp, 0).In(loc)
+		seconds := NextBucketStart(start, interval).Sub(start).Seconds()
+		result = append(result, Point{Timestamp: p.Timestamp, Value: p.Value / seconds})
+	}
+	return result, nil
+}
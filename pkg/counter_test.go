@@ -0,0 +1,121 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIncreaseAndDelta(t *testing.T) {
+	at := func(min int) time.Time {
+		return time.Date(2023, 1, 1, 0, min, 0, 0, time.UTC)
+	}
+	a := Labels{"host": "a"}.Key()
+	b := Labels{"host": "b"}.Key()
+	input := []Transaction{
+		{Value: 10, Timestamp: at(0), Labels: a},
+		{Value: 100, Timestamp: at(1), Labels: b},
+		{Value: 15, Timestamp: at(2), Labels: a},
+		{Value: 3, Timestamp: at(3), Labels: a},
+		{Value: 130, Timestamp: at(4), Labels: b},
+		{Value: 8, Timestamp: at(5), Labels: a},
+	}
+	tests := []struct {
+		name     string
+		f        func([]Transaction) []Transaction
+		expected []Transaction
+	}{
+		{
+			name: "Increase detects resets",
+			f:    Increase,
+			expected: []Transaction{
+				{Value: 5, Timestamp: at(2), Labels: a},
+				{Value: 3, Timestamp: at(3), Labels: a},
+				{Value: 30, Timestamp: at(4), Labels: b},
+				{Value: 5, Timestamp: at(5), Labels: a},
+			},
+		},
+		{
+			name: "Delta goes negative",
+			f:    Delta,
+			expected: []Transaction{
+				{Value: 5, Timestamp: at(2), Labels: a},
+				{Value: -12, Timestamp: at(3), Labels: a},
+				{Value: 30, Timestamp: at(4), Labels: b},
+				{Value: 5, Timestamp: at(5), Labels: a},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.f(input); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("result = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCounter(t *testing.T) {
+	tests := []struct {
+		mode        string
+		aggregation string
+		expected    string
+		wantErr     bool
+	}{
+		{mode: "", aggregation: AggregationAvg, expected: ""},
+		{mode: "increase", aggregation: "", expected: CounterIncrease},
+		{mode: "RATE", aggregation: AggregationSum, expected: CounterRate},
+		{mode: "DELTA", aggregation: AggregationMax, expected: CounterDelta},
+		{mode: "RATE", aggregation: AggregationAvg, wantErr: true},
+		{mode: "INCREASE", aggregation: "P95", wantErr: true},
+		{mode: "TOTAL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseCounter(tt.mode, tt.aggregation)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCounter(%q, %q) error = %v, wantErr %v", tt.mode, tt.aggregation, err, tt.wantErr)
+		}
+		if result != tt.expected {
+			t.Errorf("ParseCounter(%q, %q) = %q, want %q", tt.mode, tt.aggregation, result, tt.expected)
+		}
+	}
+}
+
+func TestPerSecond(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstDay := time.Date(2023, 3, 26, 0, 0, 0, 0, berlin).Unix()
+	tests := []struct {
+		name     string
+		input    []Point
+		interval string
+		loc      *time.Location
+		expected []Point
+	}{
+		{name: "Hour", input: []Point{{Timestamp: 0, Value: 7200}}, interval: IntervalHour, expected: []Point{{Timestamp: 0, Value: 2}}},
+		{name: "Short DST day", input: []Point{{Timestamp: dstDay, Value: 23 * 3600}}, interval: IntervalDay, loc: berlin, expected: []Point{{Timestamp: dstDay, Value: 1}}},
+		{name: "February", input: []Point{{Timestamp: 1675209600, Value: 28 * 86400}}, interval: IntervalMonth, expected: []Point{{Timestamp: 1675209600, Value: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := PerSecond(tt.input, tt.interval, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("PerSecond() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	for _, interval := range []string{IntervalAuto, "7m"} {
+		if _, err := PerSecond([]Point{{Timestamp: 0, Value: 1}}, interval, nil); err == nil {
+			t.Errorf("PerSecond(%s) error = nil, want error", interval)
+		}
+	}
+}
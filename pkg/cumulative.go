@@ -0,0 +1,44 @@
+package graphformatter
+
+import (
+	"fmt"
+	"time"
+)
+
+// Cumulative replaces every value with the running total up to it. With
+// reset set to DAY, WEEK, MONTH or YEAR the total starts over at each such
+// boundary in loc, giving e.g. month-to-date values; an empty reset never
+// starts over. Points have to be in ascending order.
+func Cumulative(points []Point, reset string, loc *time.Location) ([]Point, error) {
+	switch reset {
+	case "", IntervalDay, IntervalWeek, IntervalMonth, "YEAR":
+	default:
+		return nil, fmt.Errorf("unknown reset period %q", reset)
+	}
+
+	result := make([]Point, 0, len(points))
+	var period time.Time
+	total := 0.0
+	for _, p := range points {
+		if reset != "" {
+			start := periodStart(time.Unix(p.Timestamp, 0), reset, loc)
+			if !start.Equal(period) {
+				period = start
+				total = 0
+			}
+		}
+		total += p.Value
+		result = append(result, Point{Timestamp: p.Timestamp, Value: total})
+	}
+	return result, nil
+}
+
+func periodStart(t time.Time, period string, loc *time.Location) time.Time {
+	if period == "YEAR" {
+		if loc == nil {
+			loc = time.UTC
+		}
+		return time.Date(t.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+	return BucketStart(t, period, loc)
+}
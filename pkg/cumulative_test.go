@@ -0,0 +1,55 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCumulative(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unix := func(month time.Month, day, hour int) int64 {
+		return time.Date(2023, month, day, hour, 0, 0, 0, time.UTC).Unix()
+	}
+	input := []Point{
+		{Timestamp: unix(1, 30, 0), Value: 1},
+		{Timestamp: unix(1, 31, 0), Value: 2},
+		{Timestamp: unix(1, 31, 16), Value: 4},
+		{Timestamp: unix(2, 1, 0), Value: 8},
+		{Timestamp: unix(12, 31, 16), Value: 16},
+	}
+	tests := []struct {
+		name     string
+		reset    string
+		loc      *time.Location
+		expected []float64
+	}{
+		{name: "Running total", expected: []float64{1, 3, 7, 15, 31}},
+		{name: "Month to date", reset: IntervalMonth, expected: []float64{1, 3, 7, 8, 16}},
+		{name: "Month to date in Tokyo", reset: IntervalMonth, loc: tokyo, expected: []float64{1, 3, 4, 12, 16}},
+		{name: "Year to date in Tokyo", reset: "YEAR", loc: tokyo, expected: []float64{1, 3, 7, 15, 16}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Cumulative(input, tt.reset, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := []float64{}
+			for _, p := range result {
+				values = append(values, p.Value)
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("Cumulative() = %v, want %v", values, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Cumulative(input, "QUARTER", nil); err == nil {
+		t.Errorf("Cumulative() error = nil, want error")
+	}
+}
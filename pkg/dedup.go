@@ -0,0 +1,79 @@
+package graphformatter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DedupValue = "VALUE"
+	DedupID    = "ID"
+)
+
+// DedupOptions configure Dedup. With the VALUE key, transactions with the
+// same value and labels are duplicates if they are at most Tolerance
+// apart. With the ID key, every transaction repeating an earlier ID is a
+// duplicate, whatever its time; transactions without ID are kept.
+type DedupOptions struct {
+	Key       string
+	Tolerance time.Duration
+}
+
+// DedupReport tells how many of the input transactions were dropped.
+type DedupReport struct {
+	Input   int `json:"input"`
+	Dropped int `json:"dropped"`
+}
+
+// Dedup drops repeated transactions, such as those of retried deliveries,
+// keeping the earliest one. A transaction is compared with the last one
+// kept for its key, so a series of retries counts once as long as each
+// is within the tolerance of the original. The remaining transactions
+// keep their order.
+func Dedup(structs []Transaction, opts DedupOptions) ([]Transaction, DedupReport, error) {
+	key := strings.ToUpper(opts.Key)
+	if key != DedupValue && key != DedupID {
+		return nil, DedupReport{}, fmt.Errorf("unknown dedup key %q", opts.Key)
+	}
+	if opts.Tolerance < 0 {
+		return nil, DedupReport{}, fmt.Errorf("negative dedup tolerance %v", opts.Tolerance)
+	}
+
+	order := make([]int, len(structs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return structs[a].Timestamp.Compare(structs[b].Timestamp)
+	})
+
+	drop := make([]bool, len(structs))
+	kept := map[string]time.Time{}
+	report := DedupReport{Input: len(structs)}
+	for _, i := range order {
+		tx := structs[i]
+		k := tx.ID
+		if key == DedupValue {
+			k = strconv.Itoa(tx.Value) + tx.Labels.String()
+		} else if k == "" {
+			continue
+		}
+		if last, ok := kept[k]; ok && (key == DedupID || tx.Timestamp.Sub(last) <= opts.Tolerance) {
+			drop[i] = true
+			report.Dropped++
+			continue
+		}
+		kept[k] = tx.Timestamp
+	}
+
+	result := make([]Transaction, 0, len(structs)-report.Dropped)
+	for i, tx := range structs {
+		if !drop[i] {
+			result = append(result, tx)
+		}
+	}
+	return result, report, nil
+}
@@ -0,0 +1,63 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	// Newest first, as returned by SliceSorter, except for the last one,
+	// an exact repeat of the value 7.
+	input := []Transaction{
+		{Value: 5, Timestamp: at(20), ID: "c"},
+		{Value: 5, Timestamp: at(8), ID: "b"},
+		{Value: 5, Timestamp: at(4), ID: "a"},
+		{Value: 5, Timestamp: at(0), ID: "a", Labels: Labels{"merchant": "x"}.Key()},
+		{Value: 7, Timestamp: at(0)},
+		{Value: 5, Timestamp: at(0), ID: "a"},
+		{Value: 7, Timestamp: at(0)},
+	}
+	tests := []struct {
+		name     string
+		opts     DedupOptions
+		expected []int
+		wantErr  bool
+	}{
+		{name: "Same timestamp", opts: DedupOptions{Key: DedupValue}, expected: []int{0, 1, 2, 3, 4, 5}},
+		{name: "Retries within tolerance", opts: DedupOptions{Key: DedupValue, Tolerance: 5 * time.Second}, expected: []int{0, 1, 3, 4, 5}},
+		{name: "Compared with the kept one", opts: DedupOptions{Key: "value", Tolerance: 8 * time.Second}, expected: []int{0, 3, 4, 5}},
+		{name: "ID", opts: DedupOptions{Key: DedupID}, expected: []int{0, 1, 3, 4, 6}},
+		{name: "Unknown key", opts: DedupOptions{Key: "HASH"}, wantErr: true},
+		{name: "Negative tolerance", opts: DedupOptions{Key: DedupValue, Tolerance: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, report, err := Dedup(input, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Dedup() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []Transaction{}
+			for _, i := range tt.expected {
+				expected = append(expected, input[i])
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Dedup() = %v, want %v", result, expected)
+			}
+			if want := (DedupReport{Input: len(input), Dropped: len(input) - len(expected)}); report != want {
+				t.Errorf("Dedup() report = %v, want %v", report, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package graphformatter
+
+import (
+	"math"
+	"slices"
+)
+
+// LTTB downsamples structs to at most threshold transactions with the
+// Largest-Triangle-Three-Buckets algorithm, which keeps the points that
+// shape the chart, such as peaks and troughs. The first and the last
+// transaction are always kept, so thresholds below 3 are raised to 3. The
+// result has the order of the input, so the output of SliceSorter stays
+// newest first.
+func LTTB(structs []Transaction, threshold int) []Transaction {
+	threshold = max(threshold, 3)
+	if threshold >= len(structs) {
+		return slices.Clone(structs)
+	}
+	sorted := ascending(structs)
+
+	result := make([]Transaction, 0, threshold)
+	result = append(result, sorted[0])
+	every := float64(len(sorted)-2) / float64(threshold-2)
+	selected := 0
+	for i := 0; i < threshold-2; i++ {
+		start := int(float64(i)*every) + 1
+		end := int(float64(i+1)*every) + 1
+
+		nextStart, nextEnd := end, min(int(float64(i+2)*every)+1, len(sorted))
+		if i == threshold-3 {
+			end = len(sorted) - 1
+			nextStart, nextEnd = len(sorted)-1, len(sorted)
+		}
+		avgX, avgY := 0.0, 0.0
+		for _, tx := range sorted[nextStart:nextEnd] {
+			avgX += seconds(tx)
+			avgY += float64(tx.Value)
+		}
+		avgX /= float64(nextEnd - nextStart)
+		avgY /= float64(nextEnd - nextStart)
+
+		ax, ay := seconds(sorted[selected]), float64(sorted[selected].Value)
+		maxArea := -1.0
+		for j := start; j < end; j++ {
+			area := math.Abs((ax-avgX)*(float64(sorted[j].Value)-ay) - (ax-seconds(sorted[j]))*(avgY-ay))
+			if area > maxArea {
+				maxArea = area
+				selected = j
+			}
+		}
+		result = append(result, sorted[selected])
+	}
+	result = append(result, sorted[len(sorted)-1])
+	return sameOrder(structs, result)
+}
+
+// M4 splits the time range of structs into width columns and keeps the
+// first, last, smallest and largest transaction of each, which renders
+// identically to the full data on a chart that is width pixels wide.
+func M4(structs []Transaction, width int) []Transaction {
+	if len(structs) == 0 || width < 1 {
+		return []Transaction{}
+	}
+	sorted := ascending(structs)
+	from, to := seconds(sorted[0]), seconds(sorted[len(sorted)-1])
+	column := func(tx Transaction) int {
+		if to == from {
+			return 0
+		}
+		return min(int((seconds(tx)-from)/(to-from)*float64(width)), width-1)
+	}
+
+	result := []Transaction{}
+	for start := 0; start < len(sorted); {
+		end, minIndex, maxIndex := start, start, start
+		for end < len(sorted) && column(sorted[end]) == column(sorted[start]) {
+			if sorted[end].Value < sorted[minIndex].Value {
+				minIndex = end
+			}
+			if sorted[end].Value > sorted[maxIndex].Value {
+				maxIndex = end
+			}
+			end++
+		}
+		indices := []int{start, minIndex, maxIndex, end - 1}
+		slices.Sort(indices)
+		for _, i := range slices.Compact(indices) {
+			result = append(result, sorted[i])
+		}
+		start = end
+	}
+	return sameOrder(structs, result)
+}
+
+func seconds(tx Transaction) float64 {
+	return float64(tx.Timestamp.Unix()) + float64(tx.Timestamp.Nanosecond())/1e9
+}
+
+// sameOrder reverses the ascending result if structs is newest first.
+func sameOrder(structs, result []Transaction) []Transaction {
+	if len(structs) > 1 && structs[0].Timestamp.After(structs[len(structs)-1].Timestamp) {
+		result = slices.Clone(result)
+		slices.Reverse(result)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package graphformatter
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func downsampleInput(n int) []Transaction {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	structs := make([]Transaction, n)
+	for i := range structs {
+		structs[i] = Transaction{Value: int(100 * math.Sin(float64(i)/50)), Timestamp: start.Add(time.Duration(i) * time.Minute)}
+	}
+	if n > 1234 {
+		structs[777].Value = 1000
+		structs[1234].Value = -1000
+	}
+	return structs
+}
+
+func TestLTTB(t *testing.T) {
+	input := downsampleInput(5000)
+	result := LTTB(input, 100)
+
+	if len(result) != 100 {
+		t.Fatalf("len(LTTB()) = %d, want 100", len(result))
+	}
+	if !reflect.DeepEqual(result[0], input[0]) || !reflect.DeepEqual(result[99], input[4999]) {
+		t.Errorf("LTTB() does not keep the first and the last transaction")
+	}
+	for _, spike := range []Transaction{input[777], input[1234]} {
+		found := false
+		for _, tx := range result {
+			found = found || reflect.DeepEqual(tx, spike)
+		}
+		if !found {
+			t.Errorf("LTTB() dropped the spike %v", spike)
+		}
+	}
+
+	descending := SliceSorter(downsampleInput(5000))
+	reversed := LTTB(descending, 100)
+	for i := range reversed {
+		if !reflect.DeepEqual(reversed[i], result[len(result)-1-i]) {
+			t.Fatalf("LTTB() of newest first input is not the reversed result")
+		}
+	}
+}
+
+func TestLTTBSmallInput(t *testing.T) {
+	input := downsampleInput(10)
+	if result := LTTB(input, 20); !reflect.DeepEqual(result, input) {
+		t.Errorf("LTTB() with a threshold above the input size = %v, want the input", result)
+	}
+	if result := LTTB(input, 1); len(result) != 3 {
+		t.Errorf("len(LTTB()) with threshold 1 = %d, want 3", len(result))
+	}
+}
+
+func TestM4(t *testing.T) {
+	input := downsampleInput(5000)
+	result := M4(input, 50)
+
+	if len(result) > 4*50 {
+		t.Errorf("len(M4()) = %d, want at most %d", len(result), 4*50)
+	}
+	for _, spike := range []Transaction{input[0], input[777], input[1234], input[4999]} {
+		found := false
+		for _, tx := range result {
+			found = found || reflect.DeepEqual(tx, spike)
+		}
+		if !found {
+			t.Errorf("M4() dropped %v", spike)
+		}
+	}
+	for i := 1; i < len(result); i++ {
+		if !result[i].Timestamp.After(result[i-1].Timestamp) {
+			t.Fatalf("M4() result is not in ascending order")
+		}
+	}
+	if result := M4(nil, 50); len(result) != 0 {
+		t.Errorf("M4() of no transactions = %v, want none", result)
+	}
+}
@@ -0,0 +1,433 @@
+package graphformatter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Predicate reports whether a transaction is kept by Filter.
+type Predicate func(Transaction) bool
+
+// Filter returns the transactions of structs that p keeps, in order.
+func Filter(structs []Transaction, p Predicate) []Transaction {
+	result := []Transaction{}
+	for _, tx := range structs {
+		if p(tx) {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
+
+// Clamp limits the values of structs to the range from lo to hi, clipping
+// outliers instead of dropping them like a filter would.
+func Clamp(structs []Transaction, lo, hi int) []Transaction {
+	result := make([]Transaction, 0, len(structs))
+	for _, tx := range structs {
+		tx.Value = min(max(tx.Value, lo), hi)
+		result = append(result, tx)
+	}
+	return result
+}
+
+// ParseClamp reads a value range of the form min:max for Clamp.
+func ParseClamp(s string) (int, int, error) {
+	first, second, ok := strings.Cut(s, ":")
+	lo, loErr := strconv.Atoi(strings.TrimSpace(first))
+	hi, hiErr := strconv.Atoi(strings.TrimSpace(second))
+	if !ok || loErr != nil || hiErr != nil {
+		return 0, 0, fmt.Errorf("invalid range %q, use min:max", s)
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("invalid range %q, min is above max", s)
+	}
+	return lo, hi, nil
+}
+
+func (p Predicate) And(q Predicate) Predicate {
+	return func(tx Transaction) bool { return p(tx) && q(tx) }
+}
+
+func (p Predicate) Or(q Predicate) Predicate {
+	return func(tx Transaction) bool { return p(tx) || q(tx) }
+}
+
+func (p Predicate) Not() Predicate {
+	return func(tx Transaction) bool { return !p(tx) }
+}
+
+// compare applies one of the operators ==, !=, <, <=, > and >= to the
+// result c of comparing two values.
+func compare(op string, c int) (bool, error) {
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+// ValueIs keeps transactions whose value compares to v with op.
+func ValueIs(op string, v float64) (Predicate, error) {
+	if _, err := compare(op, 0); err != nil {
+		return nil, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("invalid number %v", v)
+	}
+	return func(tx Transaction) bool {
+		ok, _ := compare(op, cmpFloat(float64(tx.Value), v))
+		return ok
+	}, nil
+}
+
+// TimeIs keeps transactions whose timestamp compares to t with op.
+func TimeIs(op string, t time.Time) (Predicate, error) {
+	if _, err := compare(op, 0); err != nil {
+		return nil, err
+	}
+	return func(tx Transaction) bool {
+		ok, _ := compare(op, tx.Timestamp.Compare(t))
+		return ok
+	}, nil
+}
+
+// LabelIs keeps transactions whose label name is value, with op ==, or
+// isn't, with op !=. A missing label is the empty string.
+func LabelIs(name, op, value string) (Predicate, error) {
+	if op != "==" && op != "!=" {
+		return nil, fmt.Errorf("labels can only be compared with == and !=, not %q", op)
+	}
+	return func(tx Transaction) bool {
+		return (tx.Labels.Get(name) == value) == (op == "==")
+	}, nil
+}
+
+// Within keeps transactions on the given weekdays, all if none are given,
+// between the times of day from and to in loc. A range that ends before
+// it starts wraps around midnight; an empty one is the whole day.
+func Within(from, to time.Duration, days []time.Weekday, loc *time.Location) Predicate {
+	loc = locationOrUTC(loc)
+	return func(tx Transaction) bool {
+		t := tx.Timestamp.In(loc)
+		if len(days) > 0 && !containsWeekday(days, t.Weekday()) {
+			return false
+		}
+		clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+		switch {
+		case from == to:
+			return true
+		case from < to:
+			return from <= clock && clock < to
+		}
+		return clock >= from || clock < to
+	}
+}
+
+// BusinessHours keeps transactions from 9:00 to 17:00, Monday to Friday
+// in loc.
+func BusinessHours(loc *time.Location) Predicate {
+	return Within(9*time.Hour, 17*time.Hour, workdays, loc)
+}
+
+var workdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ParseFilter reads a filter expression. Conditions are
+//
+//	value OP NUMBER
+//	label NAME == "TEXT"  or  label NAME != "TEXT"
+//	time OP "2021-03-01"  with RFC 3339 timestamps or dates in loc
+//	time within business hours  (9:00 to 17:00, Monday to Friday)
+//	time within weekdays  or  time within weekend
+//	time within 08:00-18:00
+//
+// with OP one of ==, !=, <, <=, > and >=. Conditions are combined with
+// &&, || and !, and grouped with parentheses.
+func ParseFilter(expr string, loc *time.Location) (Predicate, error) {
+	if len(expr) > MaxFilterLength {
+		return nil, fmt.Errorf("filter is longer than %d bytes", MaxFilterLength)
+	}
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens, loc: locationOrUTC(loc)}
+	predicate, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	if tok := p.peek(); tok != "" {
+		return nil, fmt.Errorf("invalid filter %q: unexpected %q", expr, tok)
+	}
+	return predicate, nil
+}
+
+// Limits of ParseFilter, which keep hostile expressions from using up the
+// stack.
+const (
+	MaxFilterLength = 4096
+	MaxFilterDepth  = 32
+)
+
+var filterOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// tokenizeFilter splits expr into operators, quoted strings, which keep
+// their quotes, and words.
+func tokenizeFilter(expr string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(expr); {
+		if expr[i] == ' ' || expr[i] == '\t' || expr[i] == '\n' {
+			i++
+			continue
+		}
+		if expr[i] == '"' {
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("invalid filter %q: unterminated string", expr)
+			}
+			tokens = append(tokens, expr[i:end+1])
+			i = end + 1
+			continue
+		}
+		if op := filterOperator(expr[i:]); op != "" {
+			tokens = append(tokens, op)
+			i += len(op)
+			continue
+		}
+		end := i
+		for end < len(expr) && !strings.ContainsRune(" \t\n\"", rune(expr[end])) && filterOperator(expr[end:]) == "" {
+			end++
+		}
+		tokens = append(tokens, expr[i:end])
+		i = end
+	}
+	return tokens, nil
+}
+
+func filterOperator(s string) string {
+	for _, op := range filterOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+type filterParser struct {
+	tokens []string
+	loc    *time.Location
+	depth  int
+}
+
+func (p *filterParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *filterParser) next(what string) (string, error) {
+	tok := p.peek()
+	if tok == "" {
+		return "", fmt.Errorf("expected %s at the end", what)
+	}
+	p.tokens = p.tokens[1:]
+	return tok, nil
+}
+
+func (p *filterParser) or() (Predicate, error) {
+	left, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.tokens = p.tokens[1:]
+		var right Predicate
+		if right, err = p.and(); err == nil {
+			left = left.Or(right)
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) and() (Predicate, error) {
+	left, err := p.unary()
+	for err == nil && p.peek() == "&&" {
+		p.tokens = p.tokens[1:]
+		var right Predicate
+		if right, err = p.unary(); err == nil {
+			left = left.And(right)
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) unary() (Predicate, error) {
+	if tok := p.peek(); tok == "!" || tok == "(" {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > MaxFilterDepth {
+			return nil, fmt.Errorf("nested deeper than %d", MaxFilterDepth)
+		}
+	}
+	switch p.peek() {
+	case "!":
+		p.tokens = p.tokens[1:]
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return inner.Not(), nil
+	case "(":
+		p.tokens = p.tokens[1:]
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok, err := p.next(`")"`); err != nil {
+			return nil, err
+		} else if tok != ")" {
+			return nil, fmt.Errorf(`expected ")", got %q`, tok)
+		}
+		return inner, nil
+	}
+	return p.condition()
+}
+
+func (p *filterParser) condition() (Predicate, error) {
+	field, err := p.next("a condition")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(field) {
+	case "value":
+		op, operand, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", operand)
+		}
+		return ValueIs(op, v)
+	case "label":
+		name, err := p.next("a label name")
+		if err != nil {
+			return nil, err
+		}
+		op, operand, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		return LabelIs(name, op, operand)
+	case "time":
+		if strings.EqualFold(p.peek(), "within") {
+			p.tokens = p.tokens[1:]
+			return p.within()
+		}
+		op, operand, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, operand)
+		if err != nil {
+			if t, err = time.ParseInLocation("2006-01-02", operand, p.loc); err != nil {
+				return nil, fmt.Errorf("invalid time %q, use RFC 3339 or a date", operand)
+			}
+		}
+		return TimeIs(op, t)
+	}
+	return nil, fmt.Errorf("unknown field %q, want value, label or time", field)
+}
+
+// comparison reads an operator and its operand, unquoting strings.
+func (p *filterParser) comparison() (string, string, error) {
+	op, err := p.next("an operator")
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := compare(op, 0); err != nil {
+		return "", "", err
+	}
+	operand, err := p.next("an operand")
+	if err != nil {
+		return "", "", err
+	}
+	if strings.HasPrefix(operand, `"`) {
+		unquoted, err := strconv.Unquote(operand)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid string %s", operand)
+		}
+		operand = unquoted
+	} else if filterOperator(operand) != "" {
+		return "", "", fmt.Errorf("expected an operand, got %q", operand)
+	}
+	return op, operand, nil
+}
+
+func (p *filterParser) within() (Predicate, error) {
+	word, err := p.next("business hours, weekdays, weekend or a range of times")
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(word) {
+	case "business":
+		if hours, err := p.next("hours"); err != nil || !strings.EqualFold(hours, "hours") {
+			return nil, fmt.Errorf(`expected "hours" after "business"`)
+		}
+		return BusinessHours(p.loc), nil
+	case "weekdays":
+		return Within(0, 0, workdays, p.loc), nil
+	case "weekend":
+		return Within(0, 0, []time.Weekday{time.Saturday, time.Sunday}, p.loc), nil
+	}
+	start, end, ok := strings.Cut(word, "-")
+	from, fromErr := parseClock(start)
+	to, toErr := parseClock(end)
+	if !ok || fromErr != nil || toErr != nil {
+		return nil, fmt.Errorf("invalid time range %q, use HH:MM-HH:MM", word)
+	}
+	return Within(from, to, nil, p.loc), nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
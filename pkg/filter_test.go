@@ -0,0 +1,131 @@
+package graphformatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tuesday 16 March 2021 and Saturday 20 March 2021, in UTC.
+	input := []Transaction{
+		{Value: 5, Timestamp: time.Date(2021, 3, 16, 7, 30, 0, 0, time.UTC), Labels: Labels{"merchant": "x"}.Key()},
+		{Value: -2, Timestamp: time.Date(2021, 3, 16, 12, 0, 0, 0, time.UTC), Labels: Labels{"merchant": "y"}.Key()},
+		{Value: 20000, Timestamp: time.Date(2021, 3, 16, 16, 30, 0, 0, time.UTC)},
+		{Value: 50, Timestamp: time.Date(2021, 3, 20, 12, 0, 0, 0, time.UTC), Labels: Labels{"merchant": "x"}.Key()},
+	}
+	tests := []struct {
+		expr     string
+		expected []int
+		wantErr  bool
+	}{
+		{expr: "value > 0 && value < 10000", expected: []int{0, 3}},
+		{expr: "value >= 50 || value == -2", expected: []int{1, 2, 3}},
+		{expr: `label merchant == "x"`, expected: []int{0, 3}},
+		{expr: `label merchant != x && !(value < 0)`, expected: []int{2}},
+		{expr: "time within business hours", expected: []int{1, 2}},
+		{expr: "time within weekend", expected: []int{3}},
+		{expr: "time within 16:00-08:00", expected: []int{0, 2}},
+		{expr: `time >= "2021-03-17" || value<0`, expected: []int{1, 3}},
+		{expr: "time < 2021-03-16T12:00:00Z", expected: []int{0}},
+		{expr: "value >", wantErr: true},
+		{expr: "value > ten", wantErr: true},
+		{expr: "amount > 1", wantErr: true},
+		{expr: "label merchant < x", wantErr: true},
+		{expr: `label merchant == "x`, wantErr: true},
+		{expr: "(value > 1", wantErr: true},
+		{expr: "value > 1 value < 2", wantErr: true},
+		{expr: strings.Repeat("(", 32) + "value == -2" + strings.Repeat(")", 32), expected: []int{1}},
+		{expr: "time within office hours", wantErr: true},
+		{expr: "value > NaN", wantErr: true},
+		{expr: "value < +Inf", wantErr: true},
+		{expr: `label merchant == "\x"`, wantErr: true},
+		{expr: strings.Repeat("!", 100000) + "value > 0", wantErr: true},
+		{expr: strings.Repeat("(", 33) + "value > 0" + strings.Repeat(")", 33), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			predicate, err := ParseFilter(tt.expr, time.UTC)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseFilter() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []Transaction{}
+			for _, i := range tt.expected {
+				expected = append(expected, input[i])
+			}
+			if result := Filter(input, predicate); !reflect.DeepEqual(result, expected) {
+				t.Errorf("Filter() = %v, want %v", result, expected)
+			}
+		})
+	}
+
+	t.Run("business hours in Berlin", func(t *testing.T) {
+		predicate, err := ParseFilter("time within business hours", berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 7:30 UTC is 8:30 in Berlin, 16:30 UTC is 17:30.
+		expected := []Transaction{input[1]}
+		if result := Filter(input, predicate); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Filter() = %v, want %v", result, expected)
+		}
+	})
+}
+
+func TestPredicateBuilder(t *testing.T) {
+	positive, err := ValueIs(">", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merchant, err := LabelIs("merchant", "==", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	predicate := positive.And(merchant.Not()).Or(BusinessHours(nil))
+	input := []Transaction{
+		{Value: 1, Timestamp: time.Date(2021, 3, 20, 12, 0, 0, 0, time.UTC), Labels: Labels{"merchant": "x"}.Key()},
+		{Value: 1, Timestamp: time.Date(2021, 3, 20, 12, 0, 0, 0, time.UTC)},
+		{Value: -1, Timestamp: time.Date(2021, 3, 19, 12, 0, 0, 0, time.UTC), Labels: Labels{"merchant": "x"}.Key()},
+	}
+	expected := []Transaction{input[1], input[2]}
+	if result := Filter(input, predicate); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Filter() = %v, want %v", result, expected)
+	}
+	if _, err := ValueIs("~", 1); err == nil {
+		t.Errorf("ValueIs() error = nil, want error")
+	}
+}
+
+func TestClamp(t *testing.T) {
+	at := time.Unix(0, 0).UTC()
+	input := []Transaction{{Value: -5, Timestamp: at}, {Value: 50, Timestamp: at}, {Value: 20000, Timestamp: at}}
+	lo, hi, err := ParseClamp("0:10000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Transaction{{Value: 0, Timestamp: at}, {Value: 50, Timestamp: at}, {Value: 10000, Timestamp: at}}
+	if result := Clamp(input, lo, hi); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Clamp() = %v, want %v", result, expected)
+	}
+	if input[0].Value != -5 {
+		t.Errorf("Clamp() changed its input")
+	}
+
+	for _, s := range []string{"10", "a:b", "10:0"} {
+		if _, _, err := ParseClamp(s); err == nil {
+			t.Errorf("ParseClamp(%q) error = nil, want error", s)
+		}
+	}
+}
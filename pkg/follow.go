@@ -0,0 +1,79 @@
+package graphformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Follower reads the JSON Lines transactions appended to a file, like
+// tail -f. A file that shrinks is assumed to be truncated or rotated and
+// is read again from the start; Poll reports it so that callers can drop
+// what they aggregated from the old content.
+type Follower struct {
+	path    string
+	offset  int64
+	line    int
+	partial []byte
+}
+
+func NewFollower(path string) *Follower {
+	return &Follower{path: path}
+}
+
+// Poll returns the transactions of the lines completed since the last
+// call; a last line without a newline is kept until it is complete. reset
+// is true if the file shrank and the transactions are read from its start.
+// Lines that aren't valid transactions are skipped and reported in the
+// error along with the transactions of the other lines.
+func (f *Follower) Poll() (structs []Transaction, reset bool, err error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	if info.Size() < f.offset {
+		f.offset, f.line, f.partial = 0, 0, nil
+		reset = true
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, reset, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, reset, err
+	}
+	f.offset += int64(len(data))
+
+	data = append(f.partial, data...)
+	end := bytes.LastIndexByte(data, '\n') + 1
+	f.partial = append([]byte(nil), data[end:]...)
+
+	structs = []Transaction{}
+	if end == 0 {
+		return structs, reset, nil
+	}
+	var errs []error
+	for _, line := range bytes.Split(data[:end-1], []byte("\n")) {
+		f.line++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		var tx TransactionJSON
+		if err := decoder.Decode(&tx); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", f.line, err))
+			continue
+		}
+		structs = append(structs, tx.Transaction())
+	}
+	return structs, reset, errors.Join(errs...)
+}
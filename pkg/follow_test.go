@@ -0,0 +1,111 @@
+package graphformatter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFollower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+	write := func(data string, flag int) {
+		file, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	values := func(structs []Transaction) []int {
+		result := []int{}
+		for _, tx := range structs {
+			result = append(result, tx.Value)
+		}
+		return result
+	}
+
+	follower := NewFollower(path)
+	if _, _, err := follower.Poll(); err == nil {
+		t.Errorf("Poll() error = nil, want error for a missing file")
+	}
+
+	steps := []struct {
+		name     string
+		data     string
+		flag     int
+		expected []int
+		reset    bool
+		wantErr  bool
+	}{
+		{name: "Existing lines", data: "{\"value\":1,\"timestamp\":1}\n\n{\"value\":2,\"timestamp\":2}\n", flag: os.O_APPEND, expected: []int{1, 2}},
+		{name: "Nothing new", flag: os.O_APPEND, expected: []int{}},
+		{name: "Partial line", data: "{\"value\":3,", flag: os.O_APPEND, expected: []int{}},
+		{name: "Completed line", data: "\"timestamp\":3}\n", flag: os.O_APPEND, expected: []int{3}},
+		{name: "Invalid line", data: "oops\n{\"value\":4,\"timestamp\":4}\n", flag: os.O_APPEND, expected: []int{4}, wantErr: true},
+		{name: "Truncated", data: "{\"value\":5,\"timestamp\":5}\n", flag: os.O_TRUNC, expected: []int{5}, reset: true},
+	}
+	for _, step := range steps {
+		write(step.data, step.flag)
+		structs, reset, err := follower.Poll()
+		if (err != nil) != step.wantErr {
+			t.Errorf("%s: Poll() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if reset != step.reset {
+			t.Errorf("%s: Poll() reset = %v, want %v", step.name, reset, step.reset)
+		}
+		if result := values(structs); !reflect.DeepEqual(result, step.expected) {
+			t.Errorf("%s: Poll() = %v, want %v", step.name, result, step.expected)
+		}
+	}
+}
+
+func TestFollowerRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.jsonl")
+	data := "{\"value\":1,\"timestamp\":0}\n{\"value\":2,\"timestamp\":7200}\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Interval: IntervalHour}
+	follower := NewFollower(path)
+	var stream *Stream
+	var closed []Bucket
+	poll := func() []Point {
+		structs, reset, err := follower.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stream == nil || reset {
+			if stream, err = NewStream(opts, 0); err != nil {
+				t.Fatal(err)
+			}
+			closed = nil
+		}
+		for _, tx := range structs {
+			closed = append(closed, stream.Add(tx)...)
+		}
+		return Points(append(closed, stream.Open()...), AggregationSum)
+	}
+	expected := []Point{{Timestamp: 0, Value: 1}, {Timestamp: 7200, Value: 2}}
+	if result := poll(); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("first Poll() = %v, want %v", result, expected)
+	}
+
+	// Truncated and written again: the points must match the new content,
+	// not add up with the old one.
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if result := poll(); len(result) != 0 {
+		t.Errorf("Poll() after truncate = %v, want no points", result)
+	}
+	data = "{\"value\":1,\"timestamp\":0}\n{\"value\":2,\"timestamp\":7200}\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result := poll(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Poll() after rewrite = %v, want %v", result, expected)
+	}
+}
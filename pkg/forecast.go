@@ -0,0 +1,241 @@
+package graphformatter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	ForecastLinear      = "LINEAR"
+	ForecastNaive       = "NAIVE"
+	ForecastHoltWinters = "HOLTWINTERS"
+)
+
+// ForecastOptions configure Forecast. Season is DAY or WEEK and sets the
+// seasonality of NAIVE and HOLTWINTERS. Level is the coverage of the
+// prediction interval, 0.95 if unset. Alpha, Beta and Gamma are the
+// Holt-Winters smoothing factors for level, trend and season, 0.3, 0.05
+// and 0.1 if unset.
+type ForecastOptions struct {
+	Method  string
+	Horizon int
+	Season  string
+	Level   float64
+	Alpha   float64
+	Beta    float64
+	Gamma   float64
+}
+
+// ForecastPoint is a predicted bucket with its prediction interval.
+type ForecastPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+	Label     string  `json:"label,omitempty"`
+}
+
+// Forecast extends a bucketed series by opts.Horizon buckets of interval.
+// LINEAR fits a least squares trend, NAIVE repeats the last season and
+// HOLTWINTERS applies additive triple exponential smoothing. Empty
+// buckets within the series count as zero.
+func Forecast(points []Point, interval string, loc *time.Location, opts ForecastOptions) ([]ForecastPoint, error) {
+	if interval == IntervalAuto || !validInterval(interval) {
+		return nil, fmt.Errorf("unknown interval %q", interval)
+	}
+	if opts.Horizon < 1 {
+		return nil, fmt.Errorf("forecast horizon must be at least one bucket, got %d", opts.Horizon)
+	}
+	if opts.Level <= 0 || opts.Level >= 1 {
+		opts.Level = 0.95
+	}
+	z := math.Sqrt2 * math.Erfinv(opts.Level)
+	if len(points) == 0 {
+		return []ForecastPoint{}, nil
+	}
+
+	values := fillGaps(points, interval, loc)
+	var predictions, deviations []float64
+	var err error
+	switch strings.ToUpper(opts.Method) {
+	case ForecastLinear:
+		predictions, deviations, err = linearForecast(values, opts.Horizon)
+	case ForecastNaive, ForecastHoltWinters:
+		season, seasonErr := seasonLength(interval, opts.Season)
+		if seasonErr != nil {
+			return nil, seasonErr
+		}
+		if strings.ToUpper(opts.Method) == ForecastNaive {
+			predictions, deviations, err = naiveForecast(values, season, opts.Horizon)
+		} else {
+			predictions, deviations, err = holtWintersForecast(values, season, opts)
+		}
+	default:
+		return nil, fmt.Errorf("unknown forecast method %q", opts.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ForecastPoint, 0, opts.Horizon)
+	start := time.Unix(points[len(points)-1].Timestamp, 0).In(locationOrUTC(loc))
+	for h := range predictions {
+		start = NextBucketStart(start, interval)
+		result = append(result, ForecastPoint{
+			Timestamp: start.Unix(),
+			Value:     predictions[h],
+			Lower:     predictions[h] - z*deviations[h],
+			Upper:     predictions[h] + z*deviations[h],
+		})
+	}
+	return result, nil
+}
+
+// fillGaps returns the values of consecutive buckets from the first to the
+// last point, with zero for missing buckets.
+func fillGaps(points []Point, interval string, loc *time.Location) []float64 {
+	loc = locationOrUTC(loc)
+	values := []float64{}
+	start := time.Unix(points[0].Timestamp, 0).In(loc)
+	for _, p := range points {
+		for start.Unix() < p.Timestamp {
+			values = append(values, 0)
+			start = NextBucketStart(start, interval)
+		}
+		values = append(values, p.Value)
+		start = NextBucketStart(start, interval)
+	}
+	return values
+}
+
+func seasonLength(interval, season string) (int, error) {
+	season = strings.ToUpper(season)
+	if season != IntervalDay && season != IntervalWeek {
+		return 0, fmt.Errorf("unknown season %q", season)
+	}
+	m := int(intervalLength(season) / intervalLength(interval))
+	if m < 2 {
+		return 0, fmt.Errorf("%s buckets have no %s seasonality", interval, season)
+	}
+	return m, nil
+}
+
+func linearForecast(values []float64, horizon int) ([]float64, []float64, error) {
+	n := float64(len(values))
+	if len(values) < 3 {
+		return nil, nil, errors.New("a linear forecast needs at least three buckets")
+	}
+	meanX, meanY := (n-1)/2, 0.0
+	for _, v := range values {
+		meanY += v
+	}
+	meanY /= n
+	sxx, sxy := 0.0, 0.0
+	for i, v := range values {
+		sxx += (float64(i) - meanX) * (float64(i) - meanX)
+		sxy += (float64(i) - meanX) * (v - meanY)
+	}
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	sse := 0.0
+	for i, v := range values {
+		residual := v - (intercept + slope*float64(i))
+		sse += residual * residual
+	}
+	sigma := math.Sqrt(sse / (n - 2))
+
+	predictions := make([]float64, horizon)
+	deviations := make([]float64, horizon)
+	for h := range predictions {
+		x := n + float64(h)
+		predictions[h] = intercept + slope*x
+		deviations[h] = sigma * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+	}
+	return predictions, deviations, nil
+}
+
+func naiveForecast(values []float64, season, horizon int) ([]float64, []float64, error) {
+	if len(values) <= season {
+		return nil, nil, fmt.Errorf("a seasonal naive forecast needs more than %d buckets", season)
+	}
+	sse := 0.0
+	for i := season; i < len(values); i++ {
+		residual := values[i] - values[i-season]
+		sse += residual * residual
+	}
+	sigma := math.Sqrt(sse / float64(len(values)-season))
+
+	last := values[len(values)-season:]
+	predictions := make([]float64, horizon)
+	deviations := make([]float64, horizon)
+	for h := range predictions {
+		predictions[h] = last[h%season]
+		deviations[h] = sigma * math.Sqrt(float64(h/season+1))
+	}
+	return predictions, deviations, nil
+}
+
+func holtWintersForecast(values []float64, season int, opts ForecastOptions) ([]float64, []float64, error) {
+	if len(values) < 2*season {
+		return nil, nil, fmt.Errorf("a Holt-Winters forecast needs at least %d buckets", 2*season)
+	}
+	alpha, beta, gamma := opts.Alpha, opts.Beta, opts.Gamma
+	if alpha <= 0 {
+		alpha = 0.3
+	}
+	if beta <= 0 {
+		beta = 0.05
+	}
+	if gamma <= 0 {
+		gamma = 0.1
+	}
+
+	first, second := 0.0, 0.0
+	for i := 0; i < season; i++ {
+		first += values[i]
+		second += values[season+i]
+	}
+	level := first / float64(season)
+	trend := (second - first) / float64(season*season)
+	seasonal := make([]float64, season)
+	for i := range seasonal {
+		seasonal[i] = values[i] - level
+	}
+
+	sse := 0.0
+	for i := season; i < len(values); i++ {
+		s := seasonal[i%season]
+		residual := values[i] - (level + trend + s)
+		sse += residual * residual
+		previous := level
+		level = alpha*(values[i]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+		seasonal[i%season] = gamma*(values[i]-level) + (1-gamma)*s
+	}
+	sigma := math.Sqrt(sse / float64(len(values)-season))
+
+	predictions := make([]float64, opts.Horizon)
+	deviations := make([]float64, opts.Horizon)
+	variance := 1.0
+	for h := range predictions {
+		predictions[h] = level + float64(h+1)*trend + seasonal[(len(values)+h)%season]
+		deviations[h] = sigma * math.Sqrt(variance)
+		c := alpha * (1 + float64(h+1)*beta)
+		if (h+1)%season == 0 {
+			c += gamma * (1 - alpha)
+		}
+		variance += c * c
+	}
+	return predictions, deviations, nil
+}
+
+func locationOrUTC(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,94 @@
+package graphformatter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecast(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Four weeks of hourly data: a daily pattern on top of a slow trend.
+	points := []Point{}
+	for h := 0; h < 4*7*24; h++ {
+		ts := start.Add(time.Duration(h) * time.Hour)
+		value := 100 + 0.1*float64(h) + 20*math.Sin(2*math.Pi*float64(ts.Hour())/24)
+		points = append(points, Point{Timestamp: ts.Unix(), Value: value})
+	}
+	expected := func(h int) float64 {
+		ts := start.Add(time.Duration(h) * time.Hour)
+		return 100 + 0.1*float64(h) + 20*math.Sin(2*math.Pi*float64(ts.Hour())/24)
+	}
+
+	tests := []struct {
+		name      string
+		opts      ForecastOptions
+		tolerance float64
+	}{
+		{name: "Naive", opts: ForecastOptions{Method: ForecastNaive, Horizon: 24, Season: IntervalDay}, tolerance: 3},
+		{name: "Holt-Winters", opts: ForecastOptions{Method: ForecastHoltWinters, Horizon: 24, Season: IntervalDay}, tolerance: 2},
+		{name: "Linear", opts: ForecastOptions{Method: ForecastLinear, Horizon: 24}, tolerance: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Forecast(points, IntervalHour, nil, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != tt.opts.Horizon {
+				t.Fatalf("len(Forecast()) = %d, want %d", len(result), tt.opts.Horizon)
+			}
+			for i, p := range result {
+				h := len(points) + i
+				if p.Timestamp != start.Add(time.Duration(h)*time.Hour).Unix() {
+					t.Fatalf("Forecast()[%d].Timestamp = %d, want the next bucket", i, p.Timestamp)
+				}
+				if math.Abs(p.Value-expected(h)) > tt.tolerance {
+					t.Errorf("Forecast()[%d] = %v, want %v within %v", i, p.Value, expected(h), tt.tolerance)
+				}
+				if !(p.Lower <= p.Value && p.Value <= p.Upper) {
+					t.Errorf("Forecast()[%d] = %v, outside its interval [%v, %v]", i, p.Value, p.Lower, p.Upper)
+				}
+			}
+		})
+	}
+}
+
+func TestForecastErrors(t *testing.T) {
+	points := []Point{{Timestamp: 0, Value: 1}, {Timestamp: 3600, Value: 2}, {Timestamp: 7200, Value: 3}}
+	tests := []struct {
+		name     string
+		interval string
+		opts     ForecastOptions
+	}{
+		{name: "Unknown method", interval: IntervalHour, opts: ForecastOptions{Method: "ARIMA", Horizon: 1}},
+		{name: "No horizon", interval: IntervalHour, opts: ForecastOptions{Method: ForecastLinear}},
+		{name: "Too short for a season", interval: IntervalHour, opts: ForecastOptions{Method: ForecastHoltWinters, Horizon: 1, Season: IntervalDay}},
+		{name: "Unknown season", interval: IntervalHour, opts: ForecastOptions{Method: ForecastNaive, Horizon: 1, Season: "MONTH"}},
+		{name: "Season shorter than the interval", interval: IntervalWeek, opts: ForecastOptions{Method: ForecastNaive, Horizon: 1, Season: IntervalDay}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Forecast(points, tt.interval, nil, tt.opts); err == nil {
+				t.Errorf("Forecast() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	points := []Point{{Timestamp: 0, Value: 1}, {Timestamp: 7200, Value: 3}, {Timestamp: 10800, Value: 4}}
+	result := fillGaps(points, IntervalHour, nil)
+	expected := []float64{1, 0, 3, 4}
+	if len(result) != len(expected) {
+		t.Fatalf("fillGaps() = %v, want %v", result, expected)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("fillGaps() = %v, want %v", result, expected)
+			break
+		}
+	}
+}
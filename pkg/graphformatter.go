@@ -8,6 +8,8 @@ import (
 type Transaction struct {
 	Value		int
 	Timestamp 	time.Time
+	Labels		LabelKey
+	ID		string
 }
 
 func NewTransaction(Value int, Timestamp int64) *Transaction {
@@ -17,112 +19,151 @@ func NewTransaction(Value int, Timestamp int64) *Transaction {
 
 func TimeDifferenceMonth(structs []Transaction) []Transaction {
 	result := []Transaction{}
+	index := newChainIndex(len(structs), func(i int) date {
+		return dateOf(structs[i].Timestamp)
+	})
 	for i := 0; i < len(structs); i++ {
-		for j := i + 1; j < len(structs); j++ {
-			t1 := structs[i].Timestamp
-			t2 := structs[j].Timestamp
-			oneMonthLater := t1.AddDate(0, -1, 0)
-			if i == 0 && 
-			oneMonthLater.Year() == t2.Year() && 
-			oneMonthLater.Month() == t2.Month() && 
-			oneMonthLater.Day() == t2.Day() {
-				structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[i], structs[j])
-				i = j-1
-				break
-			} else if i != 0 && 
-			oneMonthLater.Year() == t2.Year() && 
-			oneMonthLater.Month() == t2.Month() && 
-			oneMonthLater.Day() == t2.Day() {
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[j])
-				i = j-1
-				break				
-			}
+		oneMonthLater := structs[i].Timestamp.AddDate(0, -1, 0)
+		j := index.next(dateOf(oneMonthLater), i)
+		if j < 0 {
+			continue
 		}
+		if i == 0 {
+			structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
+			result = append(result, structs[i])
+		}
+		structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
+		result = append(result, structs[j])
+		i = j - 1
 	}
 	return result
 }
 
 func TimeDifferenceWeek(structs []Transaction) []Transaction {
 	result := []Transaction{}
+	index := newChainIndex(len(structs), func(i int) int {
+		_, w := structs[i].Timestamp.ISOWeek()
+		return w
+	})
 	for i := 0; i < len(structs); i++ {
-		for j := i + 1; j < len(structs); j++ {
-			_, w1 := structs[i].Timestamp.ISOWeek()
-			_, w2 := structs[j].Timestamp.ISOWeek()
-			weekDifference := w1 - w2
-			if weekDifference == 1 && i == 0 {
-				structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[i], structs[j])
-				i = j-1
-				break
-			} else if  weekDifference == 1 && i > 0 {
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[j])
-				i = j-1
-				break
-			}		
+		_, w1 := structs[i].Timestamp.ISOWeek()
+		j := index.next(w1-1, i)
+		if j < 0 {
+			continue
 		}
+		if i == 0 {
+			structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
+			result = append(result, structs[i])
+		}
+		structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
+		result = append(result, structs[j])
+		i = j - 1
 	}
 	return result
 }
 
 func TimeDifferenceDay(structs []Transaction) []Transaction {
 	result := []Transaction{}
+	index := newChainIndex(len(structs), func(i int) int {
+		_, _, d := structs[i].Timestamp.Date()
+		return d
+	})
 	for i := 0; i < len(structs); i++ {
-		for j := i + 1; j < len(structs); j++ {
-			_, _, d1 := structs[i].Timestamp.Date()
-			_, _, d2 := structs[j].Timestamp.Date()
-			dayDifference := d1 - d2
-			if dayDifference == 1 && i == 0 {
-				structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[i], structs[j])
-				i = j-1
-				break
-			} else if dayDifference == 1 && i > 0 {
-				structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
-				result = append(result, structs[j])
-				i = j-1
-				break
-			}	
+		_, _, d1 := structs[i].Timestamp.Date()
+		j := index.next(d1-1, i)
+		if j < 0 {
+			continue
+		}
+		if i == 0 {
+			structs[i].Timestamp = roundToMidnight(structs[i].Timestamp)
+			result = append(result, structs[i])
 		}
+		structs[j].Timestamp = roundToMidnight(structs[j].Timestamp)
+		result = append(result, structs[j])
+		i = j - 1
 	}
 	return result
 }
 
 func TimeDifferenceHour(structs []Transaction) []Transaction {
 	result := []Transaction{}
+	index := newChainIndex(len(structs), func(i int) instant {
+		return instantOf(structs[i].Timestamp)
+	})
 	for i := 0; i < len(structs); i++ {
-		for j := i + 1; j < len(structs); j++ {
-			t1 := structs[i].Timestamp
-			t2 := structs[j].Timestamp
-			duration := t1.Sub(t2)
-			hour := time.Hour
-			if i == 0 && j == 1 && duration == hour {
-				structs[i].Timestamp = roundToNearestHour(structs[i].Timestamp)
-				structs[j].Timestamp = roundToNearestHour(structs[j].Timestamp)
-				result = append(result, structs[i], structs[j])
-				i = j-1
-				break
-			} else if i == 0 && j == 1 && duration != hour{
-				structs[i].Timestamp = roundToNearestHour(structs[i].Timestamp)
-				result = append(result, structs[i])
-			} 
-
-			if duration == hour{
-				structs[j].Timestamp = roundToNearestHour(structs[j].Timestamp)
-				result = append(result, structs[j])
-				i = j-1
-				break
-			}	
+		after := i
+		if i == 0 && len(structs) > 1 {
+			if structs[0].Timestamp.Sub(structs[1].Timestamp) == time.Hour {
+				structs[0].Timestamp = roundToNearestHour(structs[0].Timestamp)
+				structs[1].Timestamp = roundToNearestHour(structs[1].Timestamp)
+				result = append(result, structs[0], structs[1])
+				continue
+			}
+			// The first transaction is kept even without a pair, and the
+			// rest of the search compares against its rounded time.
+			structs[0].Timestamp = roundToNearestHour(structs[0].Timestamp)
+			result = append(result, structs[0])
+			after = 1
 		}
+		j := index.next(instantOf(structs[i].Timestamp.Add(-time.Hour)), after)
+		if j < 0 {
+			continue
+		}
+		structs[j].Timestamp = roundToNearestHour(structs[j].Timestamp)
+		result = append(result, structs[j])
+		i = j - 1
 	}
 	return result
 }
 
+// chainIndex finds the first transaction after a position whose key
+// matches. Positions only move forward while walking a slice, so every
+// key keeps a cursor and the whole walk is linear.
+type chainIndex[K comparable] struct {
+	positions map[K][]int
+}
+
+func newChainIndex[K comparable](n int, key func(int) K) *chainIndex[K] {
+	index := &chainIndex[K]{positions: make(map[K][]int)}
+	for i := 0; i < n; i++ {
+		k := key(i)
+		index.positions[k] = append(index.positions[k], i)
+	}
+	return index
+}
+
+func (c *chainIndex[K]) next(key K, after int) int {
+	positions := c.positions[key]
+	for len(positions) > 0 && positions[0] <= after {
+		positions = positions[1:]
+	}
+	c.positions[key] = positions
+	if len(positions) == 0 {
+		return -1
+	}
+	return positions[0]
+}
+
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{y, m, d}
+}
+
+type instant struct {
+	sec  int64
+	nsec int
+}
+
+func instantOf(t time.Time) instant {
+	return instant{t.Unix(), t.Nanosecond()}
+}
+
 func roundToNearestHour(t time.Time) time.Time{
 	return t.Truncate(time.Hour).Add(time.Hour).UTC()
 }
@@ -157,8 +198,13 @@ func SliceSorter(structs []Transaction) []Transaction {
 }
 
 func SliceFiller(structs []Transaction, graph map[int]int64) []Transaction {
-	for key, value := range graph{
-		t := NewTransaction(key, value)
+	keys := make([]int, 0, len(graph))
+	for key := range graph {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	for _, key := range keys {
+		t := NewTransaction(key, graph[key])
 		structs = append(structs, *t)
 	}
 	return structs
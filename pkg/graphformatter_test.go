@@ -1,7 +1,10 @@
 package graphformatter
 
 import (
+	"math/rand"
 	"reflect"
+	"runtime"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -510,4 +513,74 @@ func TestRoundToNearestHour(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+var benchmarkSizes = []int{1e3, 1e4, 1e5, 1e6, 1e7}
+
+// benchmarkTransactions returns n transactions one minute apart, newest
+// first, as produced by SliceSorter.
+func benchmarkTransactions(n int) []Transaction {
+	structs := make([]Transaction, n)
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range structs {
+		structs[i] = Transaction{Value: i, Timestamp: start.Add(time.Duration(n-i) * time.Minute)}
+	}
+	return structs
+}
+
+func benchmarkTimeDifference(b *testing.B, f func([]Transaction) []Transaction) {
+	for _, n := range benchmarkSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			input := benchmarkTransactions(n)
+			structs := make([]Transaction, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				copy(structs, input)
+				b.StartTimer()
+				f(structs)
+			}
+		})
+	}
+}
+
+func BenchmarkTimeDifferenceMonth(b *testing.B) {
+	benchmarkTimeDifference(b, TimeDifferenceMonth)
+}
+
+func BenchmarkTimeDifferenceWeek(b *testing.B) {
+	benchmarkTimeDifference(b, TimeDifferenceWeek)
+}
+
+func BenchmarkTimeDifferenceDay(b *testing.B) {
+	benchmarkTimeDifference(b, TimeDifferenceDay)
+}
+
+func BenchmarkTimeDifferenceHour(b *testing.B) {
+	benchmarkTimeDifference(b, TimeDifferenceHour)
+}
+
+func BenchmarkAggregate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		sorted := benchmarkTransactions(n)
+		shuffled := benchmarkTransactions(n)
+		rand.New(rand.NewSource(1)).Shuffle(n, func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		for _, input := range []struct {
+			name    string
+			structs []Transaction
+		}{{"sorted", sorted}, {"shuffled", shuffled}} {
+			b.Run(input.name+"/"+strconv.Itoa(n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					Aggregate(input.structs, Options{Interval: IntervalHour})
+				}
+			})
+			b.Run(input.name+"/parallel/"+strconv.Itoa(n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					Aggregate(input.structs, Options{Interval: IntervalHour, Workers: runtime.NumCPU()})
+				}
+			})
+		}
+	}
+}
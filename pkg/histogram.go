@@ -0,0 +1,193 @@
+package graphformatter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Histogram counts the values of every time bucket in value bins. Bin i
+// holds values in [Edges[i], Edges[i+1]), the last bin also includes its
+// upper edge. Values outside all bins are counted as underflow or
+// overflow.
+type Histogram struct {
+	Interval string            `json:"interval"`
+	Timezone string            `json:"timezone"`
+	Edges    []float64         `json:"edges"`
+	Buckets  []HistogramBucket `json:"buckets"`
+}
+
+type HistogramBucket struct {
+	Timestamp int64 `json:"timestamp"`
+	Counts    []int `json:"counts"`
+	Underflow int   `json:"underflow"`
+	Overflow  int   `json:"overflow"`
+}
+
+// LinearBins returns the edges of n bins of equal width from min to max.
+func LinearBins(min, max float64, n int) ([]float64, error) {
+	if n < 1 || !(min < max) {
+		return nil, fmt.Errorf("invalid linear bins: %d bins from %v to %v", n, min, max)
+	}
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = min + (max-min)*float64(i)/float64(n)
+	}
+	edges[n] = max
+	return edges, nil
+}
+
+// LogBins returns the edges of n bins from min to max whose width grows
+// by a constant factor. Both bounds have to be positive.
+func LogBins(min, max float64, n int) ([]float64, error) {
+	if n < 1 || !(0 < min && min < max) {
+		return nil, fmt.Errorf("invalid log bins: %d bins from %v to %v", n, min, max)
+	}
+	edges := make([]float64, n+1)
+	for i := range edges {
+		edges[i] = min * math.Pow(max/min, float64(i)/float64(n))
+	}
+	edges[0], edges[n] = min, max
+	return edges, nil
+}
+
+// ParseBins reads a bin spec: "linear:N" or "log:N" for N bins over the
+// range of the values in structs, which then can't be empty, or
+// "edges:e0,e1,..." for explicit, strictly increasing edges.
+func ParseBins(spec string, structs []Transaction) ([]float64, error) {
+	kind, arg, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("bins %q are not of the form kind:argument", spec)
+	}
+	switch strings.ToUpper(kind) {
+	case "EDGES":
+		edges := []float64{}
+		for _, field := range strings.Split(arg, ",") {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bin edge %q", field)
+			}
+			edges = append(edges, edge)
+		}
+		if len(edges) < 2 {
+			return nil, fmt.Errorf("bin edges %q must be at least two values", arg)
+		}
+		for i := 1; i < len(edges); i++ {
+			if edges[i] <= edges[i-1] {
+				return nil, fmt.Errorf("bin edges %q must be strictly increasing", arg)
+			}
+		}
+		return edges, nil
+	case "LINEAR", "LOG":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number of bins %q", arg)
+		}
+		log := strings.ToUpper(kind) == "LOG"
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, tx := range structs {
+			v := float64(tx.Value)
+			if log && v <= 0 {
+				continue
+			}
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		if math.IsInf(min, 1) {
+			if log {
+				return nil, fmt.Errorf("no positive values to derive log bins from, use edges:e0,e1,...")
+			}
+			return nil, fmt.Errorf("no values to derive linear bins from, use edges:e0,e1,...")
+		}
+		if min == max {
+			max = min + 1
+		}
+		if log {
+			return LogBins(min, max, n)
+		}
+		return LinearBins(min, max, n)
+	}
+	return nil, fmt.Errorf("unknown bins %q", kind)
+}
+
+// NewHistogram counts the values of structs per time bucket of opts and
+// value bin of edges, which must be strictly increasing. Only buckets with
+// transactions are returned.
+func NewHistogram(structs []Transaction, opts Options, edges []float64) (Histogram, error) {
+	if err := opts.Validate(); err != nil {
+		return Histogram{}, err
+	}
+	if len(edges) < 2 {
+		return Histogram{}, fmt.Errorf("a histogram needs at least two bin edges")
+	}
+	for i := 1; i < len(edges); i++ {
+		if !(edges[i] > edges[i-1]) {
+			return Histogram{}, fmt.Errorf("bin edges %v must be strictly increasing", edges)
+		}
+	}
+	opts = opts.Resolve(structs)
+	loc := opts.location()
+
+	h := Histogram{Interval: opts.Interval, Timezone: loc.String(), Edges: edges, Buckets: []HistogramBucket{}}
+	var end time.Time
+	for _, tx := range ascending(structs) {
+		if len(h.Buckets) == 0 || !tx.Timestamp.Before(end) {
+			start := BucketStart(tx.Timestamp, opts.Interval, loc)
+			end = NextBucketStart(start, opts.Interval)
+			h.Buckets = append(h.Buckets, HistogramBucket{Timestamp: start.Unix(), Counts: make([]int, len(edges)-1)})
+		}
+		b := &h.Buckets[len(h.Buckets)-1]
+		v := float64(tx.Value)
+		switch {
+		case v < edges[0]:
+			b.Underflow++
+		case v > edges[len(edges)-1]:
+			b.Overflow++
+		default:
+			i := sort.SearchFloat64s(edges, v)
+			if i == len(edges) || edges[i] != v {
+				i--
+			}
+			b.Counts[min(i, len(b.Counts)-1)]++
+		}
+	}
+	return h, nil
+}
+
+// WriteCSV writes one row per time bucket with the counts of every bin,
+// labeled by its range, followed by underflow and overflow.
+func (h Histogram) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"timestamp"}
+	for i := 0; i+1 < len(h.Edges); i++ {
+		closing := ")"
+		if i+2 == len(h.Edges) {
+			closing = "]"
+		}
+		header = append(header, fmt.Sprintf("[%s,%s%s", formatFloat(h.Edges[i]), formatFloat(h.Edges[i+1]), closing))
+	}
+	header = append(header, "underflow", "overflow")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, b := range h.Buckets {
+		row := []string{strconv.FormatInt(b.Timestamp, 10)}
+		for _, count := range b.Counts {
+			row = append(row, strconv.Itoa(count))
+		}
+		row = append(row, strconv.Itoa(b.Underflow), strconv.Itoa(b.Overflow))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
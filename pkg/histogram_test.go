@@ -0,0 +1,105 @@
+package graphformatter
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBins(t *testing.T) {
+	structs := []Transaction{{Value: 1}, {Value: 100}, {Value: -5}, {Value: 10}}
+	tests := []struct {
+		spec     string
+		expected []float64
+		wantErr  bool
+	}{
+		{spec: "linear:3", expected: []float64{-5, 30, 65, 100}},
+		{spec: "log:2", expected: []float64{1, 10, 100}},
+		{spec: "edges:0, 10,100", expected: []float64{0, 10, 100}},
+		{spec: "edges:10,0", wantErr: true},
+		{spec: "edges:10", wantErr: true},
+		{spec: "linear:0", wantErr: true},
+		{spec: "sqrt:3", wantErr: true},
+		{spec: "linear", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			result, err := ParseBins(tt.spec, structs)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseBins() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseBins() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	t.Run("no values", func(t *testing.T) {
+		for _, input := range [][]Transaction{nil, {{Value: -5}}} {
+			if _, err := ParseBins("log:5", input); err == nil {
+				t.Errorf("ParseBins(log:5, %v) error = nil, want error", input)
+			}
+		}
+		if _, err := ParseBins("linear:5", nil); err == nil {
+			t.Errorf("ParseBins(linear:5) error = nil, want error")
+		}
+		if _, err := ParseBins("edges:0,10", nil); err != nil {
+			t.Errorf("ParseBins(edges:0,10) error = %v, want explicit edges without values", err)
+		}
+	})
+}
+
+func TestNewHistogram(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	structs := []Transaction{
+		{Value: 5, Timestamp: day.Add(time.Hour)},
+		{Value: 10, Timestamp: day.Add(2 * time.Hour)},
+		{Value: 100, Timestamp: day.Add(3 * time.Hour)},
+		{Value: -1, Timestamp: day.Add(4 * time.Hour)},
+		{Value: 50, Timestamp: day.Add(26 * time.Hour)},
+		{Value: 101, Timestamp: day.Add(27 * time.Hour)},
+	}
+	expected := Histogram{
+		Interval: IntervalDay,
+		Timezone: "UTC",
+		Edges:    []float64{0, 10, 100},
+		Buckets: []HistogramBucket{
+			{Timestamp: day.Unix(), Counts: []int{1, 2}, Underflow: 1},
+			{Timestamp: day.Add(24 * time.Hour).Unix(), Counts: []int{0, 1}, Overflow: 1},
+		},
+	}
+
+	result, err := NewHistogram(structs, Options{Interval: IntervalDay}, []float64{0, 10, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("NewHistogram() = %v, want %v", result, expected)
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	csv := "timestamp,\"[0,10)\",\"[10,100]\",underflow,overflow\n" +
+		"1672531200,1,2,1,0\n" +
+		"1672617600,0,1,0,1\n"
+	if buf.String() != csv {
+		t.Errorf("WriteCSV() = %q, want %q", buf.String(), csv)
+	}
+
+	for _, edges := range [][]float64{{0}, {0, 10, 10}, {10, 0}, {0, math.NaN()}} {
+		if _, err := NewHistogram(structs, Options{Interval: IntervalDay}, edges); err == nil {
+			t.Errorf("NewHistogram(%v) error = nil, want error", edges)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package graphformatter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReadTransactions reads transactions in their JSON wire form, either as
+// one array or as a sequence of objects such as JSON Lines.
+func ReadTransactions(r io.Reader) ([]Transaction, error) {
+	reader := bufio.NewReader(r)
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	structs := []Transaction{}
+	if array, err := startsWithArray(reader); err != nil {
+		return nil, err
+	} else if array {
+		var txs []TransactionJSON
+		if err := decoder.Decode(&txs); err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			structs = append(structs, tx.Transaction())
+		}
+		return structs, nil
+	}
+
+	for {
+		var tx TransactionJSON
+		err := decoder.Decode(&tx)
+		if err == io.EOF {
+			return structs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", len(structs)+1, err)
+		}
+		structs = append(structs, tx.Transaction())
+	}
+}
+
+func startsWithArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', reader.UnreadByte()
+	}
+}
@@ -0,0 +1,65 @@
+package graphformatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTransactions(t *testing.T) {
+	expected := []Transaction{
+		{Value: 1, Timestamp: time.Unix(1672531200, 0).UTC()},
+		{Value: 2, Timestamp: time.Unix(1672534800, 0).UTC(), Labels: Labels{"merchant": "a"}.Key()},
+	}
+	tests := []struct {
+		name     string
+		input    string
+		expected []Transaction
+		wantErr  bool
+	}{
+		{
+			name:     "Array",
+			input:    `[{"value":1,"timestamp":1672531200},{"value":2,"timestamp":1672534800,"labels":{"merchant":"a"}}]`,
+			expected: expected,
+		},
+		{
+			name:     "JSON Lines",
+			input:    "{\"value\":1,\"timestamp\":1672531200}\n{\"value\":2,\"timestamp\":1672534800,\"labels\":{\"merchant\":\"a\"}}\n",
+			expected: expected,
+		},
+		{
+			name:     "Empty",
+			input:    "\n",
+			expected: []Transaction{},
+		},
+		{
+			name:    "Unknown field",
+			input:   `{"value":1,"time":1672531200}`,
+			wantErr: true,
+		},
+		{
+			name:    "Truncated",
+			input:   `[{"value":1,"timestamp":1672531200}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReadTransactions(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ReadTransactions() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ReadTransactions() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
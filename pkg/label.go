@@ -0,0 +1,178 @@
+package graphformatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LabelShort = "SHORT"
+	LabelLong  = "LONG"
+)
+
+// Locale holds the month and weekday names used in labels, starting with
+// January and Sunday.
+type Locale struct {
+	Months        [12]string
+	ShortMonths   [12]string
+	Weekdays      [7]string
+	ShortWeekdays [7]string
+}
+
+// Locales are the built-in locales by language code.
+var Locales = map[string]Locale{
+	"en": {
+		Months:        [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:      [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		ShortWeekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		Months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths:   [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		Weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortWeekdays: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"fr": {
+		Months:        [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Weekdays:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortWeekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"es": {
+		Months:        [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		Weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortWeekdays: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+}
+
+// labelLayouts are the default layouts per interval and style. Steps of
+// minutes or hours use the HOUR layouts.
+var labelLayouts = map[string][2]string{
+	IntervalHour:  {"15:04", "Mon 2 Jan 2006 15:04"},
+	IntervalDay:   {"Mon 2 Jan", "Monday 2 January 2006"},
+	IntervalWeek:  {"W{isoweek} {isoyear}", "W{isoweek} {isoyear}, Mon 2 Jan"},
+	IntervalMonth: {"Jan 2006", "January 2006"},
+}
+
+// Labeler turns bucket starts into human-readable labels. Layouts
+// override the default layout of an interval and are Go time layouts
+// that may also contain {isoweek}, {isoyear} and {quarter}. Month and
+// weekday names are taken from Locale, English if unset. An empty Style
+// means SHORT.
+type Labeler struct {
+	Locale  Locale
+	Style   string
+	Layouts map[string]string
+}
+
+// LoadLocale returns the built-in locale of a language code like "de" or
+// "de-AT". An empty code means English.
+func LoadLocale(code string) (Locale, error) {
+	if code == "" {
+		return Locales["en"], nil
+	}
+	language, _, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	locale, ok := Locales[strings.ToLower(language)]
+	if !ok {
+		return Locale{}, fmt.Errorf("unknown locale %q", code)
+	}
+	return locale, nil
+}
+
+// Validate checks the style and that the layouts are keyed by intervals.
+func (l Labeler) Validate() error {
+	switch strings.ToUpper(l.Style) {
+	case "", LabelShort, LabelLong:
+	default:
+		return fmt.Errorf("unknown label style %q", l.Style)
+	}
+	for interval := range l.Layouts {
+		if !validInterval(interval) {
+			return fmt.Errorf("unknown interval %q", interval)
+		}
+	}
+	return nil
+}
+
+// Layout returns the layout used for buckets of interval.
+func (l Labeler) Layout(interval string) string {
+	if layout, ok := l.Layouts[interval]; ok {
+		return layout
+	}
+	if layout, ok := l.Layouts[canonicalInterval(interval)]; ok {
+		return layout
+	}
+	style := 0
+	if strings.ToUpper(l.Style) == LabelLong {
+		style = 1
+	}
+	if layouts, ok := labelLayouts[canonicalInterval(interval)]; ok {
+		return layouts[style]
+	}
+	return labelLayouts[IntervalHour][style]
+}
+
+// Label returns the label of the bucket of interval starting at t.
+func (l Labeler) Label(t time.Time, interval string) string {
+	locale := l.Locale
+	if locale.Months[0] == "" {
+		locale = Locales["en"]
+	}
+	return formatLabel(t, l.Layout(interval), locale)
+}
+
+// labelTokens are the parts of a layout that Go can't format or would
+// format in English. Longer names come first so that "Jan" doesn't match
+// the start of "January".
+var labelTokens = []string{"{isoweek}", "{isoyear}", "{quarter}", "January", "Monday", "Jan", "Mon"}
+
+func formatLabel(t time.Time, layout string, locale Locale) string {
+	var b strings.Builder
+	for layout != "" {
+		i, token := len(layout), ""
+		for _, candidate := range labelTokens {
+			if j := strings.Index(layout, candidate); j >= 0 && (j < i || j == i && len(candidate) > len(token)) {
+				i, token = j, candidate
+			}
+		}
+		b.WriteString(t.Format(layout[:i]))
+		if token == "" {
+			break
+		}
+		year, week := t.ISOWeek()
+		switch token {
+		case "{isoweek}":
+			b.WriteString(fmt.Sprintf("%02d", week))
+		case "{isoyear}":
+			b.WriteString(strconv.Itoa(year))
+		case "{quarter}":
+			b.WriteString(strconv.Itoa((int(t.Month())-1)/3 + 1))
+		case "January":
+			b.WriteString(locale.Months[t.Month()-1])
+		case "Monday":
+			b.WriteString(locale.Weekdays[t.Weekday()])
+		case "Jan":
+			b.WriteString(locale.ShortMonths[t.Month()-1])
+		case "Mon":
+			b.WriteString(locale.ShortWeekdays[t.Weekday()])
+		}
+		layout = layout[i+len(token):]
+	}
+	return b.String()
+}
+
+// LabelPoints returns a copy of points labeled as buckets of interval in
+// loc.
+func LabelPoints(points []Point, interval string, loc *time.Location, labeler Labeler) []Point {
+	loc = locationOrUTC(loc)
+	result := make([]Point, len(points))
+	for i, p := range points {
+		p.Label = labeler.Label(time.Unix(p.Timestamp, 0).In(loc), interval)
+		result[i] = p
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLabeler(t *testing.T) {
+	// Tuesday 16 March 2021, in ISO week 11.
+	start := time.Date(2021, 3, 16, 14, 0, 0, 0, time.UTC)
+	german, err := LoadLocale("de-AT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		labeler  Labeler
+		interval string
+		expected string
+	}{
+		{name: "Hour", interval: IntervalHour, expected: "14:00"},
+		{name: "Step", interval: "15m", expected: "14:00"},
+		{name: "Day", interval: IntervalDay, expected: "Tue 16 Mar"},
+		{name: "Week", interval: IntervalWeek, expected: "W11 2021"},
+		{name: "Month", interval: IntervalMonth, expected: "Mar 2021"},
+		{name: "Long day", labeler: Labeler{Style: LabelLong}, interval: IntervalDay, expected: "Tuesday 16 March 2021"},
+		{name: "German day", labeler: Labeler{Locale: german, Style: "long"}, interval: "1d", expected: "Dienstag 16 März 2021"},
+		{name: "German month", labeler: Labeler{Locale: german}, interval: IntervalMonth, expected: "Mär 2021"},
+		{name: "Quarter", labeler: Labeler{Layouts: map[string]string{IntervalMonth: "Q{quarter} 2006"}}, interval: IntervalMonth, expected: "Q1 2021"},
+		{name: "Custom layout", labeler: Labeler{Layouts: map[string]string{IntervalDay: "Monday, 02.01."}}, interval: IntervalDay, expected: "Tuesday, 16.03."},
+		{name: "Custom step layout", labeler: Labeler{Layouts: map[string]string{"6h": "Jan 2 15h"}}, interval: "6h", expected: "Mar 16 14h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.labeler.Label(start, tt.interval); result != tt.expected {
+				t.Errorf("Label() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLabelerISOYear(t *testing.T) {
+	// Monday 30 December 2024 starts the first ISO week of 2025.
+	start := time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)
+	if result := (Labeler{}).Label(start, IntervalWeek); result != "W01 2025" {
+		t.Errorf("Label() = %q, want %q", result, "W01 2025")
+	}
+}
+
+func TestLabelerValidate(t *testing.T) {
+	if err := (Labeler{Style: "MEDIUM"}).Validate(); err == nil {
+		t.Errorf("Validate() error = nil, want error for an unknown style")
+	}
+	if err := (Labeler{Layouts: map[string]string{"YEAR": "2006"}}).Validate(); err == nil {
+		t.Errorf("Validate() error = nil, want error for an unknown interval")
+	}
+	if _, err := LoadLocale("tlh"); err == nil {
+		t.Errorf("LoadLocale() error = nil, want error")
+	}
+}
+
+func TestLabelPoints(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := []Point{{Timestamp: time.Date(2021, 3, 16, 0, 0, 0, 0, tokyo).Unix(), Value: 1}}
+	expected := []Point{{Timestamp: points[0].Timestamp, Value: 1, Label: "Tue 16 Mar"}}
+	result := LabelPoints(points, IntervalDay, tokyo, Labeler{})
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("LabelPoints() = %v, want %v", result, expected)
+	}
+	if points[0].Label != "" {
+		t.Errorf("LabelPoints() modified its input")
+	}
+}
@@ -0,0 +1,68 @@
+package graphformatter
+
+import (
+	"sync"
+	"time"
+)
+
+// aggregateParallel splits the time range of structs into opts.Workers
+// consecutive partitions and aggregates them concurrently. Partitions are
+// merged in time order, so the result equals the single-threaded one.
+func aggregateParallel(structs []Transaction, opts Options) []Bucket {
+	partitions := partitionByTime(structs, opts.Workers)
+
+	results := make([][]Bucket, len(partitions))
+	var wg sync.WaitGroup
+	for i, partition := range partitions {
+		wg.Add(1)
+		go func(i int, partition []Transaction) {
+			defer wg.Done()
+			results[i] = aggregateSorted(ascending(partition), opts)
+		}(i, partition)
+	}
+	wg.Wait()
+
+	return mergeBuckets(results)
+}
+
+func partitionByTime(structs []Transaction, n int) [][]Transaction {
+	if len(structs) == 0 {
+		return nil
+	}
+	min, max := structs[0].Timestamp, structs[0].Timestamp
+	for _, tx := range structs {
+		if tx.Timestamp.Before(min) {
+			min = tx.Timestamp
+		}
+		if tx.Timestamp.After(max) {
+			max = tx.Timestamp
+		}
+	}
+
+	width := max.Sub(min)/time.Duration(n) + 1
+	partitions := make([][]Transaction, n)
+	for _, tx := range structs {
+		i := int(tx.Timestamp.Sub(min) / width)
+		if i >= n {
+			i = n - 1
+		}
+		partitions[i] = append(partitions[i], tx)
+	}
+	return partitions
+}
+
+// mergeBuckets concatenates ascending bucket lists of consecutive time
+// ranges, merging the buckets that straddle two ranges.
+func mergeBuckets(lists [][]Bucket) []Bucket {
+	result := []Bucket{}
+	for _, buckets := range lists {
+		for _, b := range buckets {
+			if len(result) > 0 && result[len(result)-1].Start.Equal(b.Start) {
+				result[len(result)-1].merge(b)
+				continue
+			}
+			result = append(result, b)
+		}
+	}
+	return result
+}
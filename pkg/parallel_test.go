@@ -0,0 +1,47 @@
+package graphformatter
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAggregateParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	structs := make([]Transaction, 10000)
+	for i := range structs {
+		offset := time.Duration(r.Int63n(int64(90 * 24 * time.Hour)))
+		structs[i] = Transaction{Value: r.Intn(2000) - 1000, Timestamp: start.Add(offset)}
+	}
+
+	for _, interval := range []string{IntervalMonth, IntervalWeek, IntervalDay, IntervalHour} {
+		expected, err := Aggregate(structs, Options{Interval: interval})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, workers := range []int{2, 3, 8, 64} {
+			t.Run(interval+"/"+strconv.Itoa(workers), func(t *testing.T) {
+				result, err := Aggregate(structs, Options{Interval: interval, Workers: workers})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(result, expected) {
+					t.Errorf("Aggregate() with %d workers differs from the single-threaded result", workers)
+				}
+			})
+		}
+	}
+}
+
+func TestAggregateParallelEmpty(t *testing.T) {
+	result, err := Aggregate([]Transaction{}, Options{Interval: IntervalDay, Workers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Aggregate() = %v, want none", result)
+	}
+}
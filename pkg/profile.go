@@ -0,0 +1,173 @@
+package graphformatter
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Profile aggregates transactions by weekday and hour of day instead of
+// consecutive buckets, showing when activity happens. Rows run from
+// Monday to Sunday, columns from hour 0 to 23 in the configured timezone.
+type Profile struct {
+	Timezone    string         `json:"timezone"`
+	Aggregation string         `json:"aggregation"`
+	Normalized  bool           `json:"normalized"`
+	Cells       [7][24]float64 `json:"cells"`
+}
+
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// NewProfile aggregates structs into weekday and hour cells. With
+// normalize, every week is first scaled to its share of that week's total
+// and the shares are averaged over the weeks, so that busy weeks don't
+// dominate the profile; weeks totaling zero are left out. This needs the
+// SUM or COUNT aggregation.
+func NewProfile(structs []Transaction, opts Options, normalize bool) (Profile, error) {
+	if err := opts.Validate(); err != nil {
+		return Profile{}, err
+	}
+	aggregation := opts.aggregation()
+	if normalize && aggregation != AggregationSum && aggregation != AggregationCount {
+		return Profile{}, fmt.Errorf("normalizing needs SUM or COUNT, not %s", aggregation)
+	}
+	loc := opts.location()
+	profile := Profile{Timezone: loc.String(), Aggregation: aggregation, Normalized: normalize}
+
+	if !normalize {
+		var cells [7][24]Bucket
+		for _, tx := range structs {
+			day, hour := profileCell(tx.Timestamp, loc)
+			if cells[day][hour].Count == 0 {
+				cells[day][hour] = opts.newBucket(time.Time{})
+			}
+			cells[day][hour].add(tx.Value)
+		}
+		for day := range cells {
+			for hour := range cells[day] {
+				profile.Cells[day][hour] = cells[day][hour].Value(aggregation)
+			}
+		}
+		return profile, nil
+	}
+
+	weeks := map[int64]*[7][24]float64{}
+	for _, tx := range structs {
+		week := BucketStart(tx.Timestamp, IntervalWeek, loc).Unix()
+		if weeks[week] == nil {
+			weeks[week] = &[7][24]float64{}
+		}
+		day, hour := profileCell(tx.Timestamp, loc)
+		if aggregation == AggregationCount {
+			weeks[week][day][hour]++
+		} else {
+			weeks[week][day][hour] += float64(tx.Value)
+		}
+	}
+	// Weeks are summed in order so that the result doesn't depend on map
+	// iteration, and weeks without a total don't count.
+	starts := make([]int64, 0, len(weeks))
+	for week := range weeks {
+		starts = append(starts, week)
+	}
+	slices.Sort(starts)
+	counted := 0
+	for _, week := range starts {
+		cells := weeks[week]
+		total := 0.0
+		for day := range cells {
+			for hour := range cells[day] {
+				total += cells[day][hour]
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		counted++
+		for day := range cells {
+			for hour := range cells[day] {
+				profile.Cells[day][hour] += cells[day][hour] / total
+			}
+		}
+	}
+	if counted == 0 {
+		return profile, nil
+	}
+	for day := range profile.Cells {
+		for hour := range profile.Cells[day] {
+			profile.Cells[day][hour] /= float64(counted)
+		}
+	}
+	return profile, nil
+}
+
+func profileCell(t time.Time, loc *time.Location) (int, int) {
+	t = t.In(loc)
+	return (int(t.Weekday()) + 6) % 7, t.Hour()
+}
+
+func (p Profile) max() float64 {
+	max := 0.0
+	for day := range p.Cells {
+		for hour := range p.Cells[day] {
+			if p.Cells[day][hour] > max {
+				max = p.Cells[day][hour]
+			}
+		}
+	}
+	return max
+}
+
+// WriteTerminal draws the profile with Unicode shade blocks, two columns
+// per hour.
+func (p Profile) WriteTerminal(w io.Writer) error {
+	var header strings.Builder
+	header.WriteString("    ")
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&header, "%-6s", fmt.Sprintf("%02d", hour))
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(header.String(), " ") + "\n")
+	max := p.max()
+	for day, weekday := range weekdays {
+		b.WriteString(weekday.String()[:3] + " ")
+		for hour := range p.Cells[day] {
+			r := shade(p.Cells[day][hour], max)
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSVG draws the profile as an SVG heatmap.
+func (p Profile) WriteSVG(w io.Writer) error {
+	const size, left, top = 16, 32, 16
+	if _, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n",
+		left+24*size, top+7*size); err != nil {
+		return err
+	}
+	for hour := 0; hour < 24; hour += 3 {
+		if err := svgText(w, left+hour*size, top-4, "start", fmt.Sprintf("%02d", hour)); err != nil {
+			return err
+		}
+	}
+	max := p.max()
+	for day, weekday := range weekdays {
+		if err := svgText(w, left-4, top+day*size+11, "end", weekday.String()[:3]); err != nil {
+			return err
+		}
+		for hour := range p.Cells[day] {
+			title := fmt.Sprintf("%s %02d:00: %s", weekday, hour, formatFloat(p.Cells[day][hour]))
+			if err := svgCell(w, left+hour*size, top+day*size, size, p.Cells[day][hour], max, title); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(w, "</svg>\n")
+	return err
+}
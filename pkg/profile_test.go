@@ -0,0 +1,102 @@
+package graphformatter
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProfile(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 2 January 2023 and the following Monday.
+	monday := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	structs := []Transaction{
+		{Value: 10, Timestamp: monday.Add(9 * time.Hour)},
+		{Value: 20, Timestamp: monday.Add(9*time.Hour + 30*time.Minute)},
+		{Value: 30, Timestamp: monday.Add(23 * time.Hour)},
+		{Value: 5, Timestamp: monday.AddDate(0, 0, 7).Add(9 * time.Hour)},
+		{Value: 15, Timestamp: monday.AddDate(0, 0, 8).Add(9 * time.Hour)},
+	}
+
+	t.Run("sum in UTC", func(t *testing.T) {
+		profile, err := NewProfile(structs, Options{Interval: IntervalHour}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profile.Cells[0][9] != 35 || profile.Cells[0][23] != 30 || profile.Cells[1][9] != 15 {
+			t.Errorf("NewProfile() = %v, want 35 and 30 on Monday and 15 on Tuesday", profile.Cells)
+		}
+	})
+
+	t.Run("average in Berlin", func(t *testing.T) {
+		profile, err := NewProfile(structs, Options{Interval: IntervalHour, Location: berlin, Aggregation: AggregationAvg}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profile.Cells[0][10] != 35.0/3 || profile.Cells[1][0] != 30 || profile.Cells[1][10] != 15 {
+			t.Errorf("NewProfile() = %v, want the cells shifted by an hour", profile.Cells)
+		}
+	})
+
+	t.Run("normalized per week", func(t *testing.T) {
+		profile, err := NewProfile(structs, Options{Interval: IntervalHour}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The first week totals 60, the second 20.
+		want := map[[2]int]float64{{0, 9}: (30.0/60 + 5.0/20) / 2, {0, 23}: 30.0 / 60 / 2, {1, 9}: 15.0 / 20 / 2}
+		for cell, value := range want {
+			if got := profile.Cells[cell[0]][cell[1]]; got != value {
+				t.Errorf("NewProfile() cell %v = %v, want %v", cell, got, value)
+			}
+		}
+	})
+
+	t.Run("normalized without empty weeks", func(t *testing.T) {
+		// The third week totals zero and doesn't count.
+		third := monday.AddDate(0, 0, 14).Add(9 * time.Hour)
+		input := append(slices.Clone(structs), Transaction{Value: 5, Timestamp: third}, Transaction{Value: -5, Timestamp: third})
+		profile, err := NewProfile(input, Options{Interval: IntervalHour}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (30.0/60 + 5.0/20) / 2; profile.Cells[0][9] != want {
+			t.Errorf("NewProfile() cell [0 9] = %v, want %v", profile.Cells[0][9], want)
+		}
+	})
+
+	t.Run("normalized average", func(t *testing.T) {
+		if _, err := NewProfile(structs, Options{Interval: IntervalHour, Aggregation: AggregationAvg}, true); err == nil {
+			t.Errorf("NewProfile() error = nil, want error")
+		}
+	})
+}
+
+func TestProfileRendering(t *testing.T) {
+	var profile Profile
+	profile.Cells[0][0] = 4
+	profile.Cells[0][1] = 1
+	profile.Cells[6][23] = 2
+
+	var terminal bytes.Buffer
+	if err := profile.WriteTerminal(&terminal); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(terminal.String(), "\n")
+	if len(lines) != 9 || !strings.HasPrefix(lines[1], "Mon ██░░  ") || !strings.HasSuffix(lines[7], "▒▒") {
+		t.Errorf("WriteTerminal() = %q", terminal.String())
+	}
+
+	var svg bytes.Buffer
+	if err := profile.WriteSVG(&svg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg.String(), "<svg") || strings.Count(svg.String(), "<rect") != 7*24 {
+		t.Errorf("WriteSVG() = %q", svg.String())
+	}
+}
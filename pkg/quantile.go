@@ -0,0 +1,185 @@
+package graphformatter
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	AggregationP50 = "P50"
+	AggregationP90 = "P90"
+	AggregationP99 = "P99"
+)
+
+// ExactQuantileLimit is the number of values a Sketch keeps verbatim
+// before it switches to approximate bins.
+const ExactQuantileLimit = 1024
+
+// SketchRelativeAccuracy is the relative error of quantiles read from
+// approximate bins.
+const SketchRelativeAccuracy = 0.01
+
+var sketchGamma = (1 + SketchRelativeAccuracy) / (1 - SketchRelativeAccuracy)
+var sketchLogGamma = math.Log(sketchGamma)
+
+// Sketch collects the values of a bucket for quantile queries. Small
+// sketches keep the values and answer exactly; larger ones fall back to
+// logarithmic bins as in DDSketch. Bins are fixed by value, so the memory
+// of a sketch is bounded by the int range and merging is exact.
+type Sketch struct {
+	values   []int
+	positive map[int]int
+	negative map[int]int
+	zero     int
+	count    int
+}
+
+func NewSketch() *Sketch {
+	return &Sketch{}
+}
+
+func (s *Sketch) Count() int {
+	return s.count
+}
+
+func (s *Sketch) Add(value int) {
+	s.count++
+	if s.positive == nil {
+		i := sort.SearchInts(s.values, value)
+		s.values = append(s.values, 0)
+		copy(s.values[i+1:], s.values[i:])
+		s.values[i] = value
+		if len(s.values) > ExactQuantileLimit {
+			s.toBins()
+		}
+		return
+	}
+	s.addToBins(value)
+}
+
+// Merge adds all values of o to s.
+func (s *Sketch) Merge(o *Sketch) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	if s.positive == nil && o.positive == nil && len(s.values)+len(o.values) <= ExactQuantileLimit {
+		s.values = mergeSorted(s.values, o.values)
+		s.count += o.count
+		return
+	}
+	if s.positive == nil {
+		s.toBins()
+	}
+	s.count += o.count
+	if o.positive == nil {
+		for _, value := range o.values {
+			s.addToBins(value)
+		}
+		return
+	}
+	for i, n := range o.positive {
+		s.positive[i] += n
+	}
+	for i, n := range o.negative {
+		s.negative[i] += n
+	}
+	s.zero += o.zero
+}
+
+// Quantile returns the value at rank q*(count-1) for q between 0 and 1.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := int(q * float64(s.count-1))
+	if s.positive == nil {
+		return float64(s.values[rank])
+	}
+
+	seen := 0
+	negative := sortedBins(s.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		seen += s.negative[negative[i]]
+		if seen > rank {
+			return -binValue(negative[i])
+		}
+	}
+	seen += s.zero
+	if seen > rank {
+		return 0
+	}
+	positive := sortedBins(s.positive)
+	for _, i := range positive {
+		seen += s.positive[i]
+		if seen > rank {
+			return binValue(i)
+		}
+	}
+	return 0
+}
+
+func (s *Sketch) toBins() {
+	s.positive = map[int]int{}
+	s.negative = map[int]int{}
+	for _, value := range s.values {
+		s.addToBins(value)
+	}
+	s.values = nil
+}
+
+func (s *Sketch) addToBins(value int) {
+	switch {
+	case value > 0:
+		s.positive[binIndex(float64(value))]++
+	case value < 0:
+		s.negative[binIndex(-float64(value))]++
+	default:
+		s.zero++
+	}
+}
+
+func binIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / sketchLogGamma))
+}
+
+func binValue(i int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1)
+}
+
+func sortedBins(bins map[int]int) []int {
+	keys := make([]int, 0, len(bins))
+	for i := range bins {
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func mergeSorted(a, b []int) []int {
+	result := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] <= b[0] {
+			result = append(result, a[0])
+			a = a[1:]
+		} else {
+			result = append(result, b[0])
+			b = b[1:]
+		}
+	}
+	result = append(result, a...)
+	return append(result, b...)
+}
+
+// parseQuantile reads aggregations of the form P<percentile>, e.g. P99.9.
+func parseQuantile(aggregation string) (float64, bool) {
+	if !strings.HasPrefix(aggregation, "P") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(aggregation[1:], 64)
+	if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+		return 0, false
+	}
+	return p / 100, true
+}
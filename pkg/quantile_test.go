@@ -0,0 +1,116 @@
+package graphformatter
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSketchExact(t *testing.T) {
+	sketch := NewSketch()
+	for _, value := range []int{5, -3, 10, 0, 7, 1, 2, 8, 4, 6, 9} {
+		sketch.Add(value)
+	}
+	tests := []struct {
+		q        float64
+		expected float64
+	}{
+		{0, -3},
+		{0.5, 5},
+		{0.9, 9},
+		{1, 10},
+	}
+
+	for _, tt := range tests {
+		if result := sketch.Quantile(tt.q); result != tt.expected {
+			t.Errorf("Quantile(%v) = %v, want %v", tt.q, result, tt.expected)
+		}
+	}
+}
+
+func TestSketchApproximate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	values := make([]int, 100000)
+	sketch := NewSketch()
+	for i := range values {
+		values[i] = int(r.ExpFloat64()*1000) - 100
+		sketch.Add(values[i])
+	}
+	sort.Ints(values)
+
+	for _, q := range []float64{0.01, 0.5, 0.9, 0.99} {
+		expected := float64(values[int(q*float64(len(values)-1))])
+		result := sketch.Quantile(q)
+		if math.Abs(result-expected) > SketchRelativeAccuracy*math.Abs(expected)+1e-9 {
+			t.Errorf("Quantile(%v) = %v, want %v within %v", q, result, expected, SketchRelativeAccuracy)
+		}
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{name: "Exact", n: 100},
+		{name: "Exact into bins", n: 1500},
+		{name: "Bins", n: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whole, a, b := NewSketch(), NewSketch(), NewSketch()
+			for i := 0; i < tt.n; i++ {
+				value := i*37%1000 - 500
+				whole.Add(value)
+				if i%2 == 0 {
+					a.Add(value)
+				} else {
+					b.Add(value)
+				}
+			}
+			a.Merge(b)
+			if !reflect.DeepEqual(a, whole) {
+				t.Errorf("Merge() differs from adding all values to one sketch")
+			}
+		})
+	}
+}
+
+func TestFormatQuantile(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := []Transaction{}
+	for i := 1; i <= 100; i++ {
+		input = append(input, Transaction{Value: i, Timestamp: day.Add(time.Duration(i) * time.Minute)})
+	}
+	tests := []struct {
+		aggregation string
+		expected    float64
+	}{
+		{AggregationP50, 50},
+		{AggregationP90, 90},
+		{AggregationP99, 99},
+		{"P99.9", 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregation, func(t *testing.T) {
+			result, err := Format(input, Options{Interval: IntervalDay, Aggregation: tt.aggregation, Workers: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != 1 || result[0].Value != tt.expected {
+				t.Errorf("Format() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	for _, aggregation := range []string{"P101", "PNaN", "P"} {
+		if _, err := Format(input, Options{Interval: IntervalDay, Aggregation: aggregation}); err == nil {
+			t.Errorf("Format() with %s: error = nil, want error", aggregation)
+		}
+	}
+}
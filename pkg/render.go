@@ -0,0 +1,75 @@
+package graphformatter
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+var shades = []rune(" ░▒▓█")
+
+// shade returns the Unicode block that represents v on a scale up to max.
+func shade(v, max float64) rune {
+	if max <= 0 || v <= 0 {
+		return shades[0]
+	}
+	i := int(math.Ceil(v / max * float64(len(shades)-1)))
+	return shades[min(i, len(shades)-1)]
+}
+
+var eighths = []rune(" ▏▎▍▌▋▊▉")
+
+// WriteBarChart draws points as horizontal bars of up to width columns,
+// scaled to the largest absolute value. Bars are labeled with the label of
+// the point or else with its start in loc.
+func WriteBarChart(w io.Writer, points []Point, loc *time.Location, width int) error {
+	loc = locationOrUTC(loc)
+	labels := make([]string, len(points))
+	labelWidth, largest := 0, 0.0
+	for i, p := range points {
+		labels[i] = p.Label
+		if labels[i] == "" {
+			labels[i] = time.Unix(p.Timestamp, 0).In(loc).Format("2006-01-02 15:04")
+		}
+		labelWidth = max(labelWidth, len([]rune(labels[i])))
+		largest = math.Max(largest, math.Abs(p.Value))
+	}
+
+	var b strings.Builder
+	for i, p := range points {
+		b.WriteString(labels[i] + strings.Repeat(" ", labelWidth-len([]rune(labels[i]))) + " ")
+		if largest > 0 {
+			n := int(math.Round(math.Abs(p.Value) / largest * float64(width) * 8))
+			b.WriteString(strings.Repeat("█", n/8))
+			if n%8 > 0 {
+				b.WriteRune(eighths[n%8])
+			}
+		}
+		fmt.Fprintf(&b, " %.6g\n", p.Value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgCell writes a square of the heatmap whose opacity grows with v on a
+// scale up to max. Empty cells are drawn light grey.
+func svgCell(w io.Writer, x, y, size int, v, max float64, title string) error {
+	fill, opacity := "#1f6feb", 0.0
+	if max > 0 && v > 0 {
+		opacity = 0.15 + 0.85*v/max
+	} else {
+		fill, opacity = "#ebedf0", 1
+	}
+	_, err := fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s" fill-opacity="%.3f"><title>%s</title></rect>`+"\n",
+		x, y, size-2, size-2, fill, opacity, html.EscapeString(title))
+	return err
+}
+
+func svgText(w io.Writer, x, y int, anchor, text string) error {
+	_, err := fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="%s" font-family="sans-serif" font-size="10" fill="#57606a">%s</text>`+"\n",
+		x, y, anchor, html.EscapeString(text))
+	return err
+}
@@ -0,0 +1,27 @@
+package graphformatter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteBarChart(t *testing.T) {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC).Unix()
+	points := []Point{
+		{Timestamp: start, Value: 4},
+		{Timestamp: start + 3600, Value: 1.5, Label: "13h"},
+		{Timestamp: start + 7200, Value: 0},
+	}
+	expected := "2023-01-01 12:00 ████ 4\n" +
+		"13h              █▌ 1.5\n" +
+		"2023-01-01 14:00  0\n"
+
+	var b bytes.Buffer
+	if err := WriteBarChart(&b, points, nil, 4); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != expected {
+		t.Errorf("WriteBarChart() = %q, want %q", b.String(), expected)
+	}
+}
@@ -0,0 +1,49 @@
+package graphformatter
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Rollup merges buckets of interval from into buckets of the coarser
+// interval to, computed in loc. Counts, sums, extremes and sketches are
+// merged, so the result equals aggregating the raw transactions with to.
+// Every fine bucket has to lie within a single coarse bucket in loc, or
+// Rollup fails: weeks can't be rolled up into months, and fine buckets
+// computed in another location only roll up where their boundaries meet,
+// such as UTC hours into days of a location whose offset is whole hours.
+func Rollup(buckets []Bucket, from, to string, loc *time.Location) ([]Bucket, error) {
+	if err := (Options{Interval: from}).Validate(); err != nil {
+		return nil, err
+	}
+	if err := (Options{Interval: to}).Validate(); err != nil {
+		return nil, err
+	}
+	if from == IntervalAuto || to == IntervalAuto {
+		return nil, fmt.Errorf("cannot roll %s up to %s", from, to)
+	}
+	if intervalLength(to) < intervalLength(from) || canonicalInterval(from) == IntervalWeek && canonicalInterval(to) == IntervalMonth {
+		return nil, fmt.Errorf("cannot roll %s up to %s", from, to)
+	}
+
+	sorted := slices.Clone(buckets)
+	slices.SortFunc(sorted, func(a, b Bucket) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	result := []Bucket{}
+	for _, b := range sorted {
+		start := BucketStart(b.Start, to, loc)
+		last := NextBucketStart(b.Start, from).Add(-time.Nanosecond)
+		if !BucketStart(last, to, loc).Equal(start) {
+			return nil, fmt.Errorf("%s bucket starting at %s does not align with %s in %s",
+				from, b.Start.Format(time.RFC3339), to, start.Location())
+		}
+		if len(result) == 0 || !result[len(result)-1].Start.Equal(start) {
+			result = append(result, Bucket{Start: start})
+		}
+		result[len(result)-1].merge(b)
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package graphformatter
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollup(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	start := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	structs := make([]Transaction, 5000)
+	for i := range structs {
+		offset := time.Duration(r.Int63n(int64(60 * 24 * time.Hour)))
+		structs[i] = Transaction{Value: r.Intn(1000), Timestamp: start.Add(offset)}
+	}
+
+	tests := []struct {
+		from string
+		to   string
+		loc  *time.Location
+	}{
+		{IntervalHour, IntervalDay, time.UTC},
+		{IntervalHour, IntervalDay, berlin},
+		{IntervalHour, IntervalWeek, berlin},
+		{IntervalHour, IntervalMonth, berlin},
+		{IntervalDay, IntervalWeek, berlin},
+		{IntervalDay, IntervalMonth, berlin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.to+"/"+tt.loc.String(), func(t *testing.T) {
+			opts := Options{Location: tt.loc, Aggregation: AggregationP90}
+			opts.Interval = tt.from
+			fine, err := Aggregate(structs, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts.Interval = tt.to
+			expected, err := Aggregate(structs, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Rollup(fine, tt.from, tt.to, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Rollup() differs from aggregating the transactions by %s", tt.to)
+			}
+		})
+	}
+}
+
+func TestRollupErrors(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hour := []Bucket{{Start: time.Date(2023, 1, 1, 18, 0, 0, 0, time.UTC), Count: 1}}
+	tests := []struct {
+		name    string
+		buckets []Bucket
+		from    string
+		to      string
+		loc     *time.Location
+	}{
+		{name: "Finer interval", from: IntervalDay, to: IntervalHour},
+		{name: "Weeks into months", from: IntervalWeek, to: IntervalMonth},
+		{name: "Unknown interval", from: IntervalDay, to: "YEAR"},
+		{name: "Misaligned timezone", buckets: hour, from: IntervalHour, to: IntervalDay, loc: kolkata},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Rollup(tt.buckets, tt.from, tt.to, tt.loc); err == nil {
+				t.Errorf("Rollup() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package graphformatter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Labels identify the series a transaction belongs to, for example
+// {merchant="a", region="eu"}.
+type Labels map[string]string
+
+// Series is the bucketed data of one label combination.
+type Series struct {
+	Labels  Labels
+	Buckets []Bucket
+}
+
+// String returns the labels in the canonical {name="value", ...} form with
+// names sorted, which also serves as the key of the series. Names other
+// than identifiers are quoted like values, so different labels never give
+// the same string.
+func (l Labels) String() string {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if identifier(name) {
+			b.WriteString(name)
+		} else {
+			b.WriteString(strconv.Quote(name))
+		}
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(l[name]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// identifier reports whether name is a letter or underscore followed by
+// letters, digits and underscores.
+func identifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// LabelKey holds labels in their canonical String form. Unlike Labels it
+// is comparable, so Transaction stays comparable too. The zero value has
+// no labels.
+type LabelKey string
+
+// Key returns the canonical form of the labels, empty without labels.
+func (l Labels) Key() LabelKey {
+	if len(l) == 0 {
+		return ""
+	}
+	return LabelKey(l.String())
+}
+
+func (k LabelKey) String() string {
+	if k == "" {
+		return "{}"
+	}
+	return string(k)
+}
+
+// Labels parses the key back into labels, nil if there are none or the
+// key wasn't made by Key.
+func (k LabelKey) Labels() Labels {
+	s, ok := strings.CutPrefix(string(k), "{")
+	if !ok {
+		return nil
+	}
+	labels := Labels{}
+	for s != "}" {
+		var name string
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil
+			}
+			name, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			end := strings.IndexByte(s, '=')
+			if end < 0 {
+				return nil
+			}
+			name, s = s[:end], s[end:]
+		}
+		if s, ok = strings.CutPrefix(s, "="); !ok {
+			return nil
+		}
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return nil
+		}
+		labels[name], _ = strconv.Unquote(quoted)
+		s = strings.TrimPrefix(s[len(quoted):], ", ")
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// Get returns the value of the label name, empty if it isn't set.
+func (k LabelKey) Get(name string) string {
+	return k.Labels()[name]
+}
+
+// Select returns the subset of labels with the given names.
+func (l Labels) Select(names []string) Labels {
+	result := Labels{}
+	for _, name := range names {
+		if value, ok := l[name]; ok {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// AggregateSeries aggregates every label combination into its own series.
+// With groupBy only the named labels tell series apart. Series are ordered
+// by their labels.
+func AggregateSeries(structs []Transaction, opts Options, groupBy ...string) ([]Series, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.Resolve(structs)
+
+	groups := map[string][]Transaction{}
+	labels := map[string]Labels{}
+	for _, tx := range structs {
+		l := tx.Labels.Labels()
+		if len(groupBy) > 0 {
+			l = l.Select(groupBy)
+		} else if l == nil {
+			l = Labels{}
+		}
+		key := l.String()
+		if _, ok := labels[key]; !ok {
+			labels[key] = l
+		}
+		groups[key] = append(groups[key], tx)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]Series, 0, len(keys))
+	for _, key := range keys {
+		buckets, err := Aggregate(groups[key], opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Series{Labels: labels[key], Buckets: buckets})
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLabelsString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Labels
+		expected string
+	}{
+		{name: "No labels", input: nil, expected: "{}"},
+		{name: "Sorted names", input: Labels{"region": "eu", "merchant": "a"}, expected: `{merchant="a", region="eu"}`},
+		{name: "Quoted values", input: Labels{"name": `a"b`}, expected: `{name="a\"b"}`},
+		{name: "Quoted names", input: Labels{`a="1", b`: "2", "": "3"}, expected: `{""="3", "a=\"1\", b"="2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.input.String(); result != tt.expected {
+				t.Errorf("String() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLabelsStringCollision(t *testing.T) {
+	a := Labels{"a": "1", "b": "2"}
+	b := Labels{`a="1", b`: "2"}
+	if a.String() == b.String() {
+		t.Errorf("String() = %s for both %v and %v", a.String(), map[string]string(a), map[string]string(b))
+	}
+}
+
+func TestLabelKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Labels
+	}{
+		{name: "No labels", input: nil},
+		{name: "Sorted names", input: Labels{"region": "eu", "merchant": "a"}},
+		{name: "Quoted values", input: Labels{"name": `a"b, c="d"`}},
+		{name: "Quoted names", input: Labels{`a="1", b`: "2", "": "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.input.Key()
+			if result := key.Labels(); !reflect.DeepEqual(result, tt.input) {
+				t.Errorf("Labels() = %v, want %v", result, tt.input)
+			}
+			if key.String() != tt.input.String() {
+				t.Errorf("String() = %s, want %s", key.String(), tt.input.String())
+			}
+			// Keys are comparable, so equal labels give equal transactions.
+			if a, b := (Transaction{Labels: key}), (Transaction{Labels: tt.input.Key()}); a != b {
+				t.Errorf("Transaction{Labels: %s} != Transaction{Labels: %s}", a.Labels, b.Labels)
+			}
+		})
+	}
+
+	for _, key := range []LabelKey{"merchant", `{merchant="a"`, `{merchant=a}`} {
+		if result := key.Labels(); result != nil {
+			t.Errorf("LabelKey(%q).Labels() = %v, want nil", key, result)
+		}
+	}
+}
+
+func TestAggregateSeries(t *testing.T) {
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := []Transaction{
+		{Value: 1, Timestamp: day.Add(time.Hour), Labels: Labels{"merchant": "a", "region": "eu"}.Key()},
+		{Value: 2, Timestamp: day.Add(2 * time.Hour), Labels: Labels{"merchant": "b", "region": "eu"}.Key()},
+		{Value: 4, Timestamp: day.Add(3 * time.Hour), Labels: Labels{"merchant": "a", "region": "us"}.Key()},
+		{Value: 8, Timestamp: day.Add(4 * time.Hour), Labels: Labels{"merchant": "a", "region": "eu"}.Key()},
+	}
+	tests := []struct {
+		name     string
+		groupBy  []string
+		expected []Series
+	}{
+		{
+			name: "Every label combination",
+			expected: []Series{
+				{Labels: Labels{"merchant": "a", "region": "eu"}, Buckets: []Bucket{{Start: day, Count: 2, Sum: 9, Min: 1, Max: 8}}},
+				{Labels: Labels{"merchant": "a", "region": "us"}, Buckets: []Bucket{{Start: day, Count: 1, Sum: 4, Min: 4, Max: 4}}},
+				{Labels: Labels{"merchant": "b", "region": "eu"}, Buckets: []Bucket{{Start: day, Count: 1, Sum: 2, Min: 2, Max: 2}}},
+			},
+		},
+		{
+			name:    "Group by region",
+			groupBy: []string{"region"},
+			expected: []Series{
+				{Labels: Labels{"region": "eu"}, Buckets: []Bucket{{Start: day, Count: 3, Sum: 11, Min: 1, Max: 8}}},
+				{Labels: Labels{"region": "us"}, Buckets: []Bucket{{Start: day, Count: 1, Sum: 4, Min: 4, Max: 4}}},
+			},
+		},
+		{
+			name:    "Group by a missing label",
+			groupBy: []string{"country"},
+			expected: []Series{
+				{Labels: Labels{}, Buckets: []Bucket{{Start: day, Count: 4, Sum: 15, Min: 1, Max: 8}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := AggregateSeries(input, Options{Interval: IntervalDay}, tt.groupBy...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("AggregateSeries() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package graphformatter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const DefaultMaxBodyBytes = 10 << 20
+
+// MaxRequestPoints is the largest max_points a request may ask for.
+const MaxRequestPoints = 10000
+
+type FormatRequest struct {
+	Transactions    []TransactionJSON `json:"transactions"`
+	Interval        string            `json:"interval"`
+	Timezone        string            `json:"timezone"`
+	Aggregation     string            `json:"aggregation"`
+	GroupBy         []string          `json:"group_by"`
+	MaxPoints       int               `json:"max_points"`
+	LabelStyle      string            `json:"label_style"`
+	Locale          string            `json:"locale"`
+	LabelLayouts    map[string]string `json:"label_layouts"`
+	Dedup           string            `json:"dedup"`
+	DedupTolerance  string            `json:"dedup_tolerance"`
+	Filter          string            `json:"filter"`
+	Clamp           string            `json:"clamp"`
+	Validate        string            `json:"validate"`
+	From            int64             `json:"from"`
+	To              int64             `json:"to"`
+	MaxDistance     string            `json:"max_distance"`
+	FutureTolerance string            `json:"future_tolerance"`
+	NoNegative      bool              `json:"no_negative"`
+}
+
+type FormatResponse struct {
+	Interval    string          `json:"interval"`
+	Timezone    string          `json:"timezone"`
+	Aggregation string          `json:"aggregation"`
+	Points      []Point         `json:"points"`
+	Comparison  []Comparison    `json:"comparison,omitempty"`
+	Anomalies   []Anomaly       `json:"anomalies,omitempty"`
+	Forecast    []ForecastPoint `json:"forecast,omitempty"`
+	Series      []SeriesJSON    `json:"series,omitempty"`
+	Dedup       *DedupReport    `json:"dedup,omitempty"`
+	Quality     *QualityReport  `json:"quality,omitempty"`
+}
+
+// SeriesJSON is the wire representation of a Series.
+type SeriesJSON struct {
+	Labels     Labels          `json:"labels"`
+	Points     []Point         `json:"points"`
+	Comparison []Comparison    `json:"comparison,omitempty"`
+	Anomalies  []Anomaly       `json:"anomalies,omitempty"`
+	Forecast   []ForecastPoint `json:"forecast,omitempty"`
+}
+
+// TransactionJSON is the wire representation of a Transaction with the
+// timestamp in Unix seconds.
+type TransactionJSON struct {
+	Value     int    `json:"value"`
+	Timestamp int64  `json:"timestamp"`
+	Labels    Labels `json:"labels,omitempty"`
+	ID        string `json:"id,omitempty"`
+}
+
+func (r FormatRequest) Options() (Options, error) {
+	loc, err := LoadLocation(r.Timezone)
+	if err != nil {
+		return Options{}, err
+	}
+	if r.MaxPoints < 0 || r.MaxPoints > MaxRequestPoints {
+		return Options{}, fmt.Errorf("max_points must be between 0 and %d, got %d", MaxRequestPoints, r.MaxPoints)
+	}
+	opts := Options{Interval: r.Interval, Location: loc, Aggregation: r.Aggregation, MaxPoints: r.MaxPoints}
+	return opts, opts.Validate()
+}
+
+// Labeler returns the labeler of the request, or nil if neither a label
+// style, a locale nor layouts are requested.
+func (r FormatRequest) Labeler() (*Labeler, error) {
+	if r.LabelStyle == "" && r.Locale == "" && len(r.LabelLayouts) == 0 {
+		return nil, nil
+	}
+	locale, err := LoadLocale(r.Locale)
+	if err != nil {
+		return nil, err
+	}
+	labeler := Labeler{Locale: locale, Style: r.LabelStyle, Layouts: r.LabelLayouts}
+	return &labeler, labeler.Validate()
+}
+
+// DedupOptions returns the deduplication of the request, or nil if none
+// is requested. The tolerance is a duration like "5s".
+func (r FormatRequest) DedupOptions() (*DedupOptions, error) {
+	if r.Dedup == "" {
+		return nil, nil
+	}
+	opts := DedupOptions{Key: r.Dedup}
+	if r.DedupTolerance != "" {
+		tolerance, err := time.ParseDuration(r.DedupTolerance)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dedup tolerance %q", r.DedupTolerance)
+		}
+		opts.Tolerance = tolerance
+	}
+	return &opts, nil
+}
+
+// ValidationOptions returns the validation of the request, or nil if none
+// is requested. Bounds and checks without a policy validate with WARN.
+// From and To are Unix seconds, zero if unbounded, and the distances are
+// durations like "24h".
+func (r FormatRequest) ValidationOptions() (*ValidationOptions, error) {
+	if r.Validate == "" && r.From == 0 && r.To == 0 && r.MaxDistance == "" && r.FutureTolerance == "" && !r.NoNegative {
+		return nil, nil
+	}
+	opts := ValidationOptions{FutureTolerance: DefaultFutureTolerance, DisallowNegative: r.NoNegative}
+	if r.Validate != "" {
+		var err error
+		if opts.Policy, opts.Policies, err = ParsePolicies(r.Validate); err != nil {
+			return nil, err
+		}
+	}
+	if r.From != 0 {
+		opts.From = time.Unix(r.From, 0)
+	}
+	if r.To != 0 {
+		opts.To = time.Unix(r.To, 0)
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		to    *time.Duration
+	}{
+		{"max distance", r.MaxDistance, &opts.MaxDistance},
+		{"future tolerance", r.FutureTolerance, &opts.FutureTolerance},
+	} {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.to = duration
+	}
+	return &opts, nil
+}
+
+func (r FormatRequest) Structs() []Transaction {
+	structs := make([]Transaction, 0, len(r.Transactions))
+	for _, tx := range r.Transactions {
+		structs = append(structs, tx.Transaction())
+	}
+	return structs
+}
+
+func (tx TransactionJSON) Transaction() Transaction {
+	t := NewTransaction(tx.Value, tx.Timestamp)
+	t.Labels = tx.Labels.Key()
+	t.ID = tx.ID
+	return *t
+}
+
+// NewFormatResponse aggregates structs into the overall series and, if any
+// transaction is labeled or groupBy is given, into one series per label set.
+func NewFormatResponse(structs []Transaction, opts Options, groupBy []string) (FormatResponse, error) {
+	if err := opts.Validate(); err != nil {
+		return FormatResponse{}, err
+	}
+	opts = opts.Resolve(structs)
+	points, err := Format(structs, opts)
+	if err != nil {
+		return FormatResponse{}, err
+	}
+	response := FormatResponse{
+		Interval:    opts.Interval,
+		Timezone:    opts.location().String(),
+		Aggregation: opts.aggregation(),
+		Points:      points,
+	}
+	if !labeled(structs, groupBy) {
+		return response, nil
+	}
+
+	series, err := AggregateSeries(structs, opts, groupBy...)
+	if err != nil {
+		return FormatResponse{}, err
+	}
+	for _, s := range series {
+		response.Series = append(response.Series, SeriesJSON{
+			Labels: s.Labels,
+			Points: Points(s.Buckets, opts.aggregation()),
+		})
+	}
+	return response, nil
+}
+
+func labeled(structs []Transaction, groupBy []string) bool {
+	if len(groupBy) > 0 {
+		return true
+	}
+	for _, tx := range structs {
+		if tx.Labels != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Transform applies f to the overall series and to every labeled series.
+func (r *FormatResponse) Transform(f func([]Point) ([]Point, error)) error {
+	points, err := f(r.Points)
+	if err != nil {
+		return err
+	}
+	r.Points = points
+	for i := range r.Series {
+		points, err := f(r.Series[i].Points)
+		if err != nil {
+			return err
+		}
+		r.Series[i].Points = points
+	}
+	return nil
+}
+
+// Compare adds the period-over-period comparison to the overall series
+// and to every labeled series.
+func (r *FormatResponse) Compare(offset Offset) error {
+	loc, err := LoadLocation(r.Timezone)
+	if err != nil {
+		return err
+	}
+	r.Comparison = Compare(r.Points, offset, loc)
+	for i := range r.Series {
+		r.Series[i].Comparison = Compare(r.Series[i].Points, offset, loc)
+	}
+	return nil
+}
+
+// DetectAnomalies annotates the overall series and every labeled series
+// with their anomalies.
+func (r *FormatResponse) DetectAnomalies(opts AnomalyOptions) error {
+	loc, err := LoadLocation(r.Timezone)
+	if err != nil {
+		return err
+	}
+	if r.Anomalies, err = DetectAnomalies(r.Points, opts, loc); err != nil {
+		return err
+	}
+	for i := range r.Series {
+		if r.Series[i].Anomalies, err = DetectAnomalies(r.Series[i].Points, opts, loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Predict forecasts the overall series and every labeled series.
+func (r *FormatResponse) Predict(opts ForecastOptions) error {
+	loc, err := LoadLocation(r.Timezone)
+	if err != nil {
+		return err
+	}
+	if r.Forecast, err = Forecast(r.Points, r.Interval, loc, opts); err != nil {
+		return err
+	}
+	for i := range r.Series {
+		if r.Series[i].Forecast, err = Forecast(r.Series[i].Points, r.Interval, loc, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Label labels the points and forecasts of the overall series and of
+// every labeled series.
+func (r *FormatResponse) Label(labeler Labeler) error {
+	if err := labeler.Validate(); err != nil {
+		return err
+	}
+	loc, err := LoadLocation(r.Timezone)
+	if err != nil {
+		return err
+	}
+	label := func(points []Point, forecast []ForecastPoint) []Point {
+		for i := range forecast {
+			forecast[i].Label = labeler.Label(time.Unix(forecast[i].Timestamp, 0).In(loc), r.Interval)
+		}
+		return LabelPoints(points, r.Interval, loc, labeler)
+	}
+	r.Points = label(r.Points, r.Forecast)
+	for i := range r.Series {
+		r.Series[i].Points = label(r.Series[i].Points, r.Series[i].Forecast)
+	}
+	return nil
+}
+
+// LoadLocation is time.LoadLocation that treats an empty name as UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// NewHandler returns the HTTP API of the formatter. Request bodies larger
+// than maxBodyBytes are rejected; zero or less selects DefaultMaxBodyBytes.
+func NewHandler(maxBodyBytes int64) http.Handler {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/format", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+		var req FormatRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				writeError(w, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		opts, err := req.Options()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		labeler, err := req.Labeler()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		dedupOpts, err := req.DedupOptions()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		validation, err := req.ValidationOptions()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		structs := req.Structs()
+		if req.Filter != "" {
+			predicate, err := ParseFilter(req.Filter, opts.Location)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			structs = Filter(structs, predicate)
+		}
+		if req.Clamp != "" {
+			lo, hi, err := ParseClamp(req.Clamp)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			structs = Clamp(structs, lo, hi)
+		}
+		var quality QualityReport
+		if validation != nil {
+			if structs, quality, err = Validate(structs, *validation); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		var report DedupReport
+		if dedupOpts != nil {
+			if structs, report, err = Dedup(structs, *dedupOpts); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		response, err := NewFormatResponse(structs, opts, req.GroupBy)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if dedupOpts != nil {
+			response.Dedup = &report
+		}
+		if validation != nil {
+			response.Quality = &quality
+		}
+		if labeler != nil {
+			if err := response.Label(*labeler); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
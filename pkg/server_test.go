@@ -0,0 +1,196 @@
+package graphformatter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "Health check",
+			method:   http.MethodGet,
+			path:     "/healthz",
+			status:   http.StatusOK,
+			expected: `{"status":"ok"}`,
+		},
+		{
+			name:   "Format",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000},{"value":4,"timestamp":1672577400}],
+				"interval":"HOUR","aggregation":"AVG"}`,
+			status:   http.StatusOK,
+			expected: `{"interval":"HOUR","timezone":"UTC","aggregation":"AVG","points":[{"timestamp":1672574400,"value":3}]}`,
+		},
+		{
+			name:   "Format by label",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000,"labels":{"merchant":"a","region":"eu"}},
+				{"value":4,"timestamp":1672577400,"labels":{"merchant":"b","region":"eu"}}],
+				"interval":"DAY","group_by":["merchant"]}`,
+			status: http.StatusOK,
+			expected: `{"interval":"DAY","timezone":"UTC","aggregation":"SUM","points":[{"timestamp":1672531200,"value":6}],` +
+				`"series":[{"labels":{"merchant":"a"},"points":[{"timestamp":1672531200,"value":2}]},` +
+				`{"labels":{"merchant":"b"},"points":[{"timestamp":1672531200,"value":4}]}]}`,
+		},
+		{
+			name:   "Format with labels",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000}],
+				"interval":"DAY","label_style":"LONG","locale":"fr"}`,
+			status: http.StatusOK,
+			expected: `{"interval":"DAY","timezone":"UTC","aggregation":"SUM",` +
+				`"points":[{"timestamp":1672531200,"value":2,"label":"dimanche 1 janvier 2023"}]}`,
+		},
+		{
+			name:   "Format deduplicated",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000,"id":"a"},{"value":2,"timestamp":1672575009,"id":"a"}],
+				"interval":"HOUR","dedup":"ID"}`,
+			status: http.StatusOK,
+			expected: `{"interval":"HOUR","timezone":"UTC","aggregation":"SUM","points":[{"timestamp":1672574400,"value":2}],` +
+				`"dedup":{"input":2,"dropped":1}}`,
+		},
+		{
+			name:   "Format filtered",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000},{"value":-4,"timestamp":1672577400}],
+				"interval":"HOUR","filter":"value > 0"}`,
+			status:   http.StatusOK,
+			expected: `{"interval":"HOUR","timezone":"UTC","aggregation":"SUM","points":[{"timestamp":1672574400,"value":2}]}`,
+		},
+		{
+			name:   "Format clamped",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000},{"value":-4,"timestamp":1672577400}],
+				"interval":"HOUR","clamp":"0:10"}`,
+			status:   http.StatusOK,
+			expected: `{"interval":"HOUR","timezone":"UTC","aggregation":"SUM","points":[{"timestamp":1672574400,"value":2}]}`,
+		},
+		{
+			name:     "Invalid filter",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"HOUR","filter":"amount > 0"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid filter \"amount \u003e 0\": unknown field \"amount\", want value, label or time"}`,
+		},
+		{
+			name:     "Invalid dedup tolerance",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"HOUR","dedup":"VALUE","dedup_tolerance":"soon"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid dedup tolerance \"soon\""}`,
+		},
+		{
+			name:     "Unknown locale",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"DAY","locale":"xx"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"unknown locale \"xx\""}`,
+		},
+		{
+			name:     "Unknown interval",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"YEAR"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"unknown interval \"YEAR\""}`,
+		},
+		{
+			name:   "Format validated",
+			method: http.MethodPost,
+			path:   "/format",
+			body: `{"transactions":[{"value":2,"timestamp":1672575000},{"value":-4,"timestamp":1672577400}],
+				"interval":"HOUR","validate":"DROP","no_negative":true}`,
+			status: http.StatusOK,
+			expected: `{"interval":"HOUR","timezone":"UTC","aggregation":"SUM","points":[{"timestamp":1672574400,"value":2}],` +
+				`"quality":{"input":2,"dropped":1,"counts":{"NEGATIVE":1},"issues":[{"kind":"NEGATIVE","index":1,"timestamp":1672577400,"value":-4,"message":"value -4 is negative"}]}}`,
+		},
+		{
+			name:     "Rejected by validation",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[{"value":2,"timestamp":1672575000}],"interval":"HOUR","validate":"REJECT","to":1672570000}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"transaction 1 rejected: timestamp 2023-01-01T12:10:00Z is outside of unbounded to 2023-01-01T10:46:40Z"}`,
+		},
+		{
+			name:     "Invalid max distance",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"HOUR","max_distance":"a week"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid max distance \"a week\""}`,
+		},
+		{
+			name:     "Too many points",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[{"value":1,"timestamp":0},{"value":1,"timestamp":2000000000}],"interval":"AUTO","max_points":2000000000}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"max_points must be between 0 and 10000, got 2000000000"}`,
+		},
+		{
+			name:     "Unknown timezone",
+			method:   http.MethodPost,
+			path:     "/format",
+			body:     `{"transactions":[],"interval":"DAY","timezone":"Mars/Olympus"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"unknown timezone \"Mars/Olympus\""}`,
+		},
+		{
+			name:   "Malformed body",
+			method: http.MethodPost,
+			path:   "/format",
+			body:   `{"transactions":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Body too large",
+			method: http.MethodPost,
+			path:   "/format",
+			body:   `{"transactions":[` + strings.Repeat(`{"value":1,"timestamp":1},`, 20) + `{}],"interval":"DAY"}`,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "Wrong method",
+			method: http.MethodGet,
+			path:   "/format",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	handler := NewHandler(256)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.expected != "" && strings.TrimSpace(rec.Body.String()) != tt.expected {
+				t.Errorf("body = %s, want %s", rec.Body.String(), tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package graphformatter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SmoothingSimple      = "SMA"
+	SmoothingExponential = "EMA"
+	SmoothingCentered    = "CENTERED"
+)
+
+// Window is the extent of a smoothing window, either a number of buckets
+// or a duration. Exactly one of the fields is set.
+type Window struct {
+	Buckets  int
+	Duration time.Duration
+}
+
+// ParseWindow reads a window given as a bucket count ("6") or as a
+// duration ("6h").
+func ParseWindow(s string) (Window, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return Window{}, fmt.Errorf("window must span at least one bucket, got %d", n)
+		}
+		return Window{Buckets: n}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return Window{}, fmt.Errorf("invalid window %q", s)
+	}
+	return Window{Duration: d}, nil
+}
+
+func (w Window) validate() error {
+	switch {
+	case w.Duration < 0, w.Duration > 0 && w.Buckets != 0:
+		return fmt.Errorf("invalid window %s", w)
+	case w.Duration == 0 && w.Buckets < 1:
+		return fmt.Errorf("window must span at least one bucket, got %d", w.Buckets)
+	}
+	return nil
+}
+
+func (w Window) String() string {
+	if w.Duration > 0 {
+		return w.Duration.String()
+	}
+	return strconv.Itoa(w.Buckets)
+}
+
+// ParseSmoothing reads a smoothing spec of the form method:window, e.g.
+// "sma:3", "ema:6h" or "centered:24h".
+func ParseSmoothing(s string) (string, Window, error) {
+	method, window, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", Window{}, fmt.Errorf("smoothing %q is not of the form method:window", s)
+	}
+	method = strings.ToUpper(method)
+	switch method {
+	case SmoothingSimple, SmoothingExponential, SmoothingCentered:
+	default:
+		return "", Window{}, fmt.Errorf("unknown smoothing %q", method)
+	}
+	w, err := ParseWindow(window)
+	return method, w, err
+}
+
+// Smooth applies the smoothing method to points, which have to be in
+// ascending order.
+func Smooth(points []Point, method string, window Window) ([]Point, error) {
+	switch method {
+	case SmoothingSimple:
+		return MovingAverage(points, window)
+	case SmoothingExponential:
+		return ExponentialMovingAverage(points, window)
+	case SmoothingCentered:
+		return CenteredMovingAverage(points, window)
+	}
+	return nil, fmt.Errorf("unknown smoothing %q", method)
+}
+
+// MovingAverage replaces every point with the mean of the trailing window
+// ending at it. Duration windows cover (t-d, t], so missing buckets
+// shorten the window instead of stretching it.
+func MovingAverage(points []Point, window Window) ([]Point, error) {
+	if err := window.validate(); err != nil {
+		return nil, err
+	}
+	return windowMean(points, func(i int) (int, int) {
+		if window.Duration > 0 {
+			from := points[i].Timestamp - durationSeconds(window.Duration)
+			lo := i
+			for lo > 0 && points[lo-1].Timestamp > from {
+				lo--
+			}
+			return lo, i
+		}
+		return max(0, i-window.Buckets+1), i
+	}), nil
+}
+
+// CenteredMovingAverage replaces every point with the mean of the window
+// centered on it. Windows are cut short at the ends of the series.
+func CenteredMovingAverage(points []Point, window Window) ([]Point, error) {
+	if err := window.validate(); err != nil {
+		return nil, err
+	}
+	return windowMean(points, func(i int) (int, int) {
+		if window.Duration > 0 {
+			half := durationSeconds(window.Duration) / 2
+			lo, hi := i, i
+			for lo > 0 && points[lo-1].Timestamp >= points[i].Timestamp-half {
+				lo--
+			}
+			for hi < len(points)-1 && points[hi+1].Timestamp <= points[i].Timestamp+half {
+				hi++
+			}
+			return lo, hi
+		}
+		before := (window.Buckets - 1) / 2
+		after := window.Buckets - 1 - before
+		return max(0, i-before), min(len(points)-1, i+after)
+	}), nil
+}
+
+// ExponentialMovingAverage weights points exponentially by age. A bucket
+// window of n uses the usual smoothing factor 2/(n+1); a duration window
+// is the time constant of the decay, which keeps the weighting right
+// across missing buckets.
+func ExponentialMovingAverage(points []Point, window Window) ([]Point, error) {
+	if err := window.validate(); err != nil {
+		return nil, err
+	}
+	result := make([]Point, 0, len(points))
+	alpha := 2 / (float64(window.Buckets) + 1)
+	for i, p := range points {
+		if i == 0 {
+			result = append(result, p)
+			continue
+		}
+		if window.Duration > 0 {
+			elapsed := float64(p.Timestamp - points[i-1].Timestamp)
+			alpha = 1 - math.Exp(-elapsed/window.Duration.Seconds())
+		}
+		previous := result[i-1].Value
+		result = append(result, Point{Timestamp: p.Timestamp, Value: previous + alpha*(p.Value-previous)})
+	}
+	return result, nil
+}
+
+func windowMean(points []Point, bounds func(i int) (int, int)) []Point {
+	result := make([]Point, 0, len(points))
+	for i, p := range points {
+		lo, hi := bounds(i)
+		sum := 0.0
+		for _, q := range points[lo : hi+1] {
+			sum += q.Value
+		}
+		result = append(result, Point{Timestamp: p.Timestamp, Value: sum / float64(hi-lo+1)})
+	}
+	return result
+}
+
+func durationSeconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
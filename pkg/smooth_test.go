@@ -0,0 +1,128 @@
+package graphformatter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseSmoothing(t *testing.T) {
+	tests := []struct {
+		input   string
+		method  string
+		window  Window
+		wantErr bool
+	}{
+		{input: "sma:3", method: SmoothingSimple, window: Window{Buckets: 3}},
+		{input: "EMA:6h", method: SmoothingExponential, window: Window{Duration: 6 * time.Hour}},
+		{input: "centered:5", method: SmoothingCentered, window: Window{Buckets: 5}},
+		{input: "sma", wantErr: true},
+		{input: "median:3", wantErr: true},
+		{input: "sma:0", wantErr: true},
+		{input: "sma:-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			method, window, err := ParseSmoothing(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSmoothing() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || window != tt.window {
+				t.Errorf("ParseSmoothing() = %s, %v, want %s, %v", method, window, tt.method, tt.window)
+			}
+		})
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	// Hourly points with the 04:00 bucket missing.
+	input := []Point{{Timestamp: 0, Value: 1}, {Timestamp: 3600, Value: 2}, {Timestamp: 7200, Value: 3}, {Timestamp: 10800, Value: 4}, {Timestamp: 18000, Value: 8}}
+	tests := []struct {
+		name     string
+		method   string
+		window   Window
+		expected []float64
+	}{
+		{
+			name:     "Simple by buckets",
+			method:   SmoothingSimple,
+			window:   Window{Buckets: 2},
+			expected: []float64{1, 1.5, 2.5, 3.5, 6},
+		},
+		{
+			name:     "Simple by duration",
+			method:   SmoothingSimple,
+			window:   Window{Duration: 2 * time.Hour},
+			expected: []float64{1, 1.5, 2.5, 3.5, 8},
+		},
+		{
+			name:     "Centered by buckets",
+			method:   SmoothingCentered,
+			window:   Window{Buckets: 3},
+			expected: []float64{1.5, 2, 3, 5, 6},
+		},
+		{
+			name:     "Centered by duration",
+			method:   SmoothingCentered,
+			window:   Window{Duration: 2 * time.Hour},
+			expected: []float64{1.5, 2, 3, 3.5, 8},
+		},
+		{
+			name:     "Exponential by buckets",
+			method:   SmoothingExponential,
+			window:   Window{Buckets: 3},
+			expected: []float64{1, 1.5, 2.25, 3.125, 5.5625},
+		},
+		{
+			name:     "Exponential by duration",
+			method:   SmoothingExponential,
+			window:   Window{Duration: time.Hour},
+			expected: []float64{1, 1.6321, 2.4968, 3.4470, 7.3838},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Smooth(input, tt.method, tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Smooth() = %v, want %v", result, tt.expected)
+			}
+			for i, p := range result {
+				if p.Timestamp != input[i].Timestamp || math.Abs(p.Value-tt.expected[i]) > 1e-4 {
+					t.Errorf("Smooth() = %v, want %v", result, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSmoothInvalidWindow(t *testing.T) {
+	input := []Point{{Timestamp: 0, Value: 1}, {Timestamp: 3600, Value: 2}}
+	functions := map[string]func([]Point, Window) ([]Point, error){
+		"MovingAverage":            MovingAverage,
+		"CenteredMovingAverage":    CenteredMovingAverage,
+		"ExponentialMovingAverage": ExponentialMovingAverage,
+	}
+	windows := []Window{{}, {Buckets: -2}, {Duration: -time.Hour}, {Buckets: 2, Duration: time.Hour}}
+	for name, f := range functions {
+		for _, window := range windows {
+			if result, err := f(input, window); err == nil {
+				t.Errorf("%s(%+v) = %v, want error", name, window, result)
+			}
+		}
+	}
+	if _, err := Smooth(input, SmoothingSimple, Window{}); err == nil {
+		t.Errorf("Smooth() error = nil, want error for a zero window")
+	}
+}
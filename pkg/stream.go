@@ -0,0 +1,108 @@
+package graphformatter
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Stream aggregates transactions one at a time without keeping them in
+// memory. Only buckets that may still receive events are held open; a
+// bucket is closed once the watermark, the latest event time seen minus
+// the allowed lateness, passes its end.
+type Stream struct {
+	opts      Options
+	lateness  time.Duration
+	watermark time.Time
+	open      map[int64]*openBucket
+	dropped   int
+}
+
+type openBucket struct {
+	Bucket
+	end time.Time
+}
+
+func NewStream(opts Options, allowedLateness time.Duration) (*Stream, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Interval == IntervalAuto {
+		return nil, errors.New("streams need a fixed interval")
+	}
+	return &Stream{opts: opts, lateness: allowedLateness, open: map[int64]*openBucket{}}, nil
+}
+
+// Add adds tx to its bucket and returns the buckets closed by the move of
+// the watermark, in ascending order. Events whose bucket has already been
+// closed are dropped and counted.
+func (s *Stream) Add(tx Transaction) []Bucket {
+	start := BucketStart(tx.Timestamp, s.opts.Interval, s.opts.location())
+	end := NextBucketStart(start, s.opts.Interval)
+	if !s.watermark.IsZero() && !end.After(s.watermark) {
+		s.dropped++
+		return nil
+	}
+
+	b, ok := s.open[start.Unix()]
+	if !ok {
+		b = &openBucket{Bucket: s.opts.newBucket(start), end: end}
+		s.open[start.Unix()] = b
+	}
+	b.add(tx.Value)
+
+	return s.Advance(tx.Timestamp.Add(-s.lateness))
+}
+
+// Advance moves the watermark to t if it is later than the current one
+// and returns the buckets that got closed. It lets callers close buckets
+// when no events arrive for a while.
+func (s *Stream) Advance(t time.Time) []Bucket {
+	if !t.After(s.watermark) {
+		return nil
+	}
+	s.watermark = t
+
+	closed := []Bucket{}
+	for key, b := range s.open {
+		if !b.end.After(t) {
+			closed = append(closed, b.Bucket)
+			delete(s.open, key)
+		}
+	}
+	sortBuckets(closed)
+	return closed
+}
+
+// Flush closes and returns all open buckets.
+func (s *Stream) Flush() []Bucket {
+	closed := s.Open()
+	s.open = map[int64]*openBucket{}
+	return closed
+}
+
+// Open returns a snapshot of the buckets that are still open.
+func (s *Stream) Open() []Bucket {
+	result := make([]Bucket, 0, len(s.open))
+	for _, b := range s.open {
+		result = append(result, b.Bucket)
+	}
+	sortBuckets(result)
+	return result
+}
+
+func (s *Stream) Watermark() time.Time {
+	return s.watermark
+}
+
+// Dropped returns the number of events that arrived after their bucket
+// was closed.
+func (s *Stream) Dropped() int {
+	return s.dropped
+}
+
+func sortBuckets(buckets []Bucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+}
@@ -0,0 +1,73 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2023, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		input    Transaction
+		expected []Bucket
+	}{
+		{name: "Opens bucket", input: Transaction{Value: 1, Timestamp: at(10, 10)}},
+		{name: "Same bucket", input: Transaction{Value: 2, Timestamp: at(10, 50)}},
+		{name: "Within lateness", input: Transaction{Value: 3, Timestamp: at(11, 20)}},
+		{name: "Out of order", input: Transaction{Value: 4, Timestamp: at(10, 40)}},
+		{
+			name:  "Watermark passes",
+			input: Transaction{Value: 5, Timestamp: at(11, 40)},
+			expected: []Bucket{
+				{Start: at(10, 0), Count: 3, Sum: 7, Min: 1, Max: 4},
+			},
+		},
+		{name: "Too late", input: Transaction{Value: 6, Timestamp: at(10, 55)}},
+	}
+
+	stream, err := NewStream(Options{Interval: IntervalHour}, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := stream.Add(tt.input)
+			if len(result) != 0 || len(tt.expected) != 0 {
+				if !reflect.DeepEqual(result, tt.expected) {
+					t.Errorf("Add() = %v, want %v", result, tt.expected)
+				}
+			}
+		})
+	}
+
+	if stream.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", stream.Dropped())
+	}
+	expected := []Bucket{{Start: at(11, 0), Count: 2, Sum: 8, Min: 3, Max: 5}}
+	if result := stream.Flush(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Flush() = %v, want %v", result, expected)
+	}
+	if result := stream.Open(); len(result) != 0 {
+		t.Errorf("Open() after Flush() = %v, want none", result)
+	}
+}
+
+func TestStreamAdvance(t *testing.T) {
+	stream, err := NewStream(Options{Interval: IntervalDay}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Add(Transaction{Value: 1, Timestamp: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)})
+
+	if result := stream.Advance(time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC)); len(result) != 0 {
+		t.Errorf("Advance() before the end of the day = %v, want none", result)
+	}
+	expected := []Bucket{{Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Count: 1, Sum: 1, Min: 1, Max: 1}}
+	if result := stream.Advance(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Advance() = %v, want %v", result, expected)
+	}
+}
@@ -0,0 +1,231 @@
+package graphformatter
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PolicyReject = "REJECT"
+	PolicyDrop   = "DROP"
+	PolicyWarn   = "WARN"
+)
+
+// DefaultFutureTolerance is how far timestamps may lie in the future by
+// default, to allow for clock skew.
+const DefaultFutureTolerance = time.Minute
+
+// Kinds of data quality issues.
+const (
+	IssueOutOfRange   = "OUT_OF_RANGE"
+	IssueFuture       = "FUTURE"
+	IssueNegative     = "NEGATIVE"
+	IssueNonMonotonic = "NON_MONOTONIC"
+)
+
+// ValidationOptions configure Validate. Timestamps before From or after
+// To, or further than MaxDistance from the median timestamp, are out of
+// range; zero values disable these bounds. Timestamps more than
+// FutureTolerance after Now, time.Now() if zero, are in the future.
+// Negative values are only checked with DisallowNegative, decreasing
+// counters only with Counter. Policy applies to every kind of issue that
+// has no entry in Policies and is WARN if empty. NON_MONOTONIC issues are
+// only warned about unless Policies sets them, since counters may reset.
+type ValidationOptions struct {
+	From             time.Time
+	To               time.Time
+	MaxDistance      time.Duration
+	Now              time.Time
+	FutureTolerance  time.Duration
+	DisallowNegative bool
+	Counter          bool
+	Policy           string
+	Policies         map[string]string
+}
+
+// Issue is a data quality problem of the transaction at Index of the
+// input.
+type Issue struct {
+	Kind      string `json:"kind"`
+	Index     int    `json:"index"`
+	Timestamp int64  `json:"timestamp"`
+	Value     int    `json:"value"`
+	Message   string `json:"message"`
+}
+
+// QualityReport lists the issues found by Validate and counts them by
+// kind.
+type QualityReport struct {
+	Input   int            `json:"input"`
+	Dropped int            `json:"dropped"`
+	Counts  map[string]int `json:"counts"`
+	Issues  []Issue        `json:"issues"`
+}
+
+// ParsePolicies reads a default policy and per kind policies, like
+// "DROP,NEGATIVE=WARN".
+func ParsePolicies(s string) (string, map[string]string, error) {
+	policy, policies := "", map[string]string{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToUpper(strings.TrimSpace(field))
+		kind, p, ok := strings.Cut(field, "=")
+		if !ok {
+			if err := checkPolicy(field); err != nil {
+				return "", nil, err
+			}
+			policy = field
+			continue
+		}
+		if err := checkIssueKind(kind); err != nil {
+			return "", nil, err
+		}
+		if err := checkPolicy(p); err != nil {
+			return "", nil, err
+		}
+		policies[kind] = p
+	}
+	return policy, policies, nil
+}
+
+func checkPolicy(policy string) error {
+	switch strings.ToUpper(policy) {
+	case "", PolicyReject, PolicyDrop, PolicyWarn:
+		return nil
+	}
+	return fmt.Errorf("unknown policy %q", policy)
+}
+
+func checkIssueKind(kind string) error {
+	switch kind {
+	case IssueOutOfRange, IssueFuture, IssueNegative, IssueNonMonotonic:
+		return nil
+	}
+	return fmt.Errorf("unknown issue kind %q", kind)
+}
+
+func (o ValidationOptions) policy(kind string) string {
+	if policy, ok := o.Policies[kind]; ok && policy != "" {
+		return strings.ToUpper(policy)
+	}
+	if o.Policy == "" || kind == IssueNonMonotonic {
+		return PolicyWarn
+	}
+	return strings.ToUpper(o.Policy)
+}
+
+// Validate checks structs for data quality issues and applies the policy
+// of every issue found: transactions with a DROP issue are left out, and
+// any REJECT issue fails the whole input. The report is returned in
+// either case. Counters are checked per label set in time order, and a
+// value lower than the one before it is an issue; transactions dropped for
+// other issues are skipped.
+func Validate(structs []Transaction, opts ValidationOptions) ([]Transaction, QualityReport, error) {
+	if err := checkPolicy(opts.Policy); err != nil {
+		return nil, QualityReport{}, err
+	}
+	for kind, policy := range opts.Policies {
+		if err := checkIssueKind(kind); err != nil {
+			return nil, QualityReport{}, err
+		}
+		if err := checkPolicy(policy); err != nil {
+			return nil, QualityReport{}, err
+		}
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	order := make([]int, len(structs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return structs[a].Timestamp.Compare(structs[b].Timestamp)
+	})
+	var median time.Time
+	if len(order) > 0 {
+		median = structs[order[len(order)/2]].Timestamp
+	}
+
+	report := QualityReport{Input: len(structs), Counts: map[string]int{}, Issues: []Issue{}}
+	issue := func(kind string, i int, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, Issue{
+			Kind:      kind,
+			Index:     i,
+			Timestamp: structs[i].Timestamp.Unix(),
+			Value:     structs[i].Value,
+			Message:   fmt.Sprintf(format, args...),
+		})
+		report.Counts[kind]++
+	}
+	previous := map[string]int{}
+	for _, i := range order {
+		tx := structs[i]
+		found := len(report.Issues)
+		switch {
+		case !opts.From.IsZero() && tx.Timestamp.Before(opts.From),
+			!opts.To.IsZero() && tx.Timestamp.After(opts.To):
+			issue(IssueOutOfRange, i, "timestamp %s is outside of %s to %s", formatTime(tx.Timestamp), formatTime(opts.From), formatTime(opts.To))
+		case opts.MaxDistance > 0 && absDuration(tx.Timestamp.Sub(median)) > opts.MaxDistance:
+			issue(IssueOutOfRange, i, "timestamp %s is more than %v from the median %s", formatTime(tx.Timestamp), opts.MaxDistance, formatTime(median))
+		}
+		if tx.Timestamp.After(now.Add(opts.FutureTolerance)) {
+			issue(IssueFuture, i, "timestamp %s is in the future", formatTime(tx.Timestamp))
+		}
+		if opts.DisallowNegative && tx.Value < 0 {
+			issue(IssueNegative, i, "value %d is negative", tx.Value)
+		}
+		dropped := false
+		for _, is := range report.Issues[found:] {
+			dropped = dropped || opts.policy(is.Kind) != PolicyWarn
+		}
+		if opts.Counter && !dropped {
+			key := tx.Labels.String()
+			if last, ok := previous[key]; ok && tx.Value < last {
+				issue(IssueNonMonotonic, i, "counter %s decreased from %d to %d", key, last, tx.Value)
+			}
+			previous[key] = tx.Value
+		}
+	}
+	sort.SliceStable(report.Issues, func(a, b int) bool {
+		return report.Issues[a].Index < report.Issues[b].Index
+	})
+
+	drop := make([]bool, len(structs))
+	for _, is := range report.Issues {
+		switch opts.policy(is.Kind) {
+		case PolicyReject:
+			return nil, report, fmt.Errorf("transaction %d rejected: %s", is.Index+1, is.Message)
+		case PolicyDrop:
+			if !drop[is.Index] {
+				drop[is.Index] = true
+				report.Dropped++
+			}
+		}
+	}
+	result := make([]Transaction, 0, len(structs)-report.Dropped)
+	for i, tx := range structs {
+		if !drop[i] {
+			result = append(result, tx)
+		}
+	}
+	return result, report, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unbounded"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,146 @@
+package graphformatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicies(t *testing.T) {
+	tests := []struct {
+		input    string
+		policy   string
+		policies map[string]string
+		wantErr  bool
+	}{
+		{input: "drop", policy: PolicyDrop, policies: map[string]string{}},
+		{input: "WARN, negative=reject,FUTURE=DROP", policy: PolicyWarn, policies: map[string]string{IssueNegative: PolicyReject, IssueFuture: PolicyDrop}},
+		{input: "IGNORE", wantErr: true},
+		{input: "STALE=DROP", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			policy, policies, err := ParsePolicies(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if policy != tt.policy || !reflect.DeepEqual(policies, tt.policies) {
+				t.Errorf("ParsePolicies() = %v, %v, want %v, %v", policy, policies, tt.policy, tt.policies)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2021, 3, 20, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2021, 3, d, 12, 0, 0, 0, time.UTC)
+	}
+	input := []Transaction{
+		{Value: 10, Timestamp: day(15)},
+		{Value: 12, Timestamp: day(16)},
+		{Value: 7, Timestamp: time.Unix(1234545757, 0).UTC()},
+		{Value: 11, Timestamp: day(17)},
+		{Value: -3, Timestamp: day(18)},
+		{Value: 20, Timestamp: day(25)},
+	}
+	base := ValidationOptions{
+		Now:              now,
+		MaxDistance:      365 * 24 * time.Hour,
+		DisallowNegative: true,
+		Counter:          true,
+	}
+
+	t.Run("Report", func(t *testing.T) {
+		result, report, err := Validate(input, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, input) {
+			t.Errorf("Validate() = %v, want the input kept with warnings", result)
+		}
+		expected := map[string]int{IssueOutOfRange: 1, IssueFuture: 1, IssueNegative: 1, IssueNonMonotonic: 2}
+		if !reflect.DeepEqual(report.Counts, expected) {
+			t.Errorf("Validate() counts = %v, want %v", report.Counts, expected)
+		}
+		kinds := []string{}
+		for _, issue := range report.Issues {
+			kinds = append(kinds, issue.Kind)
+		}
+		// The 2009 transaction comes first in time, so the counter only
+		// decreases on March 17 and 18.
+		want := []string{IssueOutOfRange, IssueNonMonotonic, IssueNegative, IssueNonMonotonic, IssueFuture}
+		if !reflect.DeepEqual(kinds, want) {
+			t.Errorf("Validate() issues = %v, want %v", kinds, want)
+		}
+	})
+
+	t.Run("Drop with exceptions", func(t *testing.T) {
+		opts := base
+		opts.Policy = PolicyDrop
+		opts.Policies = map[string]string{IssueNonMonotonic: PolicyWarn}
+		result, report, err := Validate(input, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []Transaction{input[0], input[1], input[3]}
+		if !reflect.DeepEqual(result, expected) || report.Dropped != 3 {
+			t.Errorf("Validate() = %v, dropped %d, want %v", result, report.Dropped, expected)
+		}
+	})
+
+	t.Run("Counter resets are warnings", func(t *testing.T) {
+		opts := base
+		opts.Policy = PolicyDrop
+		_, report, err := Validate(input, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Dropped != 3 || report.Counts[IssueNonMonotonic] != 1 {
+			t.Errorf("Validate() dropped %d, counts %v, want 3 dropped and 1 counter warning", report.Dropped, report.Counts)
+		}
+	})
+
+	t.Run("Counter skips dropped", func(t *testing.T) {
+		counters := []Transaction{
+			{Value: 10, Timestamp: day(15)},
+			{Value: -5, Timestamp: day(16)},
+			{Value: 12, Timestamp: day(17)},
+		}
+		opts := ValidationOptions{Now: now, DisallowNegative: true, Counter: true, Policies: map[string]string{IssueNegative: PolicyWarn, IssueNonMonotonic: PolicyDrop}}
+		_, report, err := Validate(counters, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Counts[IssueNonMonotonic] != 1 {
+			t.Fatalf("Validate() counts = %v, want the counter checked against the kept values", report.Counts)
+		}
+
+		opts.Policies[IssueNegative] = PolicyDrop
+		result, report, err := Validate(counters, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []Transaction{counters[0], counters[2]}
+		if !reflect.DeepEqual(result, expected) || report.Counts[IssueNonMonotonic] != 0 {
+			t.Errorf("Validate() = %v, counts %v, want %v without counter issues", result, report.Counts, expected)
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		opts := ValidationOptions{Now: now, From: day(1), Policies: map[string]string{IssueOutOfRange: PolicyReject}}
+		if _, report, err := Validate(input, opts); err == nil || report.Counts[IssueOutOfRange] != 1 {
+			t.Errorf("Validate() error = %v, report = %v, want a rejection", err, report)
+		}
+	})
+
+	t.Run("Unknown policy", func(t *testing.T) {
+		if _, _, err := Validate(input, ValidationOptions{Policy: "IGNORE"}); err == nil {
+			t.Errorf("Validate() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	graphformatter "github.com/HappyR0b0t/graph-formatting/pkg"
+)
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	maxBodyBytes := flags.Int64("max-body-bytes", graphformatter.DefaultMaxBodyBytes, "maximum size of a request body")
+	flags.Parse(args)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           graphformatter.NewHandler(*maxBodyBytes),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+	log.Printf("listening on %s", *addr)
+	return server.ListenAndServe()
+}
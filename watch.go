@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	graphformatter "github.com/HappyR0b0t/graph-formatting/pkg"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// watch follows the JSON Lines file at path and writes the output again
+// whenever transactions are appended. New transactions only update their
+// buckets in a stream, the file is never read twice; the output is then
+// redrawn from the buckets. A file that is truncated or rotated starts a
+// new stream. Transactions that predicate, if any, doesn't keep are
+// skipped.
+func watch(path string, opts graphformatter.Options, predicate graphformatter.Predicate, lateness, poll time.Duration, terminal bool, finish func(graphformatter.FormatResponse, io.Writer) error) error {
+	stream, err := graphformatter.NewStream(opts, lateness)
+	if err != nil {
+		return err
+	}
+	follower := graphformatter.NewFollower(path)
+	aggregation := opts.Aggregation
+	if aggregation == "" {
+		aggregation = graphformatter.AggregationSum
+	}
+	closed := []graphformatter.Bucket{}
+	dropped := 0
+	for first := true; ; first = false {
+		structs, reset, err := follower.Poll()
+		if errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+		}
+		if reset {
+			log.Printf("%s: file was truncated, reading it again", path)
+			if stream, err = graphformatter.NewStream(opts, lateness); err != nil {
+				return err
+			}
+			closed, dropped = []graphformatter.Bucket{}, 0
+		}
+		if predicate != nil {
+			structs = graphformatter.Filter(structs, predicate)
+		}
+		for _, tx := range structs {
+			closed = append(closed, stream.Add(tx)...)
+		}
+		if stream.Dropped() > dropped {
+			log.Printf("%s: dropped %d transactions that arrived after their bucket was closed", path, stream.Dropped()-dropped)
+			dropped = stream.Dropped()
+		}
+
+		if first || reset || len(structs) > 0 {
+			buckets := append(slices.Clone(closed), stream.Open()...)
+			response := graphformatter.FormatResponse{
+				Interval:    opts.Interval,
+				Timezone:    opts.Location.String(),
+				Aggregation: aggregation,
+				Points:      graphformatter.Points(buckets, aggregation),
+			}
+			var b bytes.Buffer
+			if terminal {
+				b.WriteString(clearScreen)
+			}
+			if err := finish(response, &b); err != nil {
+				return err
+			}
+			if _, err := os.Stdout.Write(b.Bytes()); err != nil {
+				return err
+			}
+		}
+		time.Sleep(poll)
+	}
+}